@@ -1,224 +1,457 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	nethttp "net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/ONSdigital/dp-apipoc-client/cache"
 	"github.com/ONSdigital/dp-apipoc-client/http"
 	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
 	"github.com/ONSdigital/dp-apipoc-client/model"
 )
 
+// ApiClient is the full client, made up of the interfaces below so that
+// consumers can depend on, mock or decorate only the part they use.
 type ApiClient interface {
+	OpsClient
+	CatalogueClient
+	SearchClient
+	DataClient
+}
+
+// OpsClient reports on the health of the API server.
+type OpsClient interface {
 	Ping() (int, error)
+	PingContext(ctx context.Context) (int, error)
 	Status() (int, model.Status, error)
+	StatusContext(ctx context.Context) (int, model.Status, error)
+}
 
+// CatalogueClient lists and describes datasets and timeseries.
+type CatalogueClient interface {
 	GetDatasets(start int, limit int) (int, model.Metadata, error)
+	GetDatasetsContext(ctx context.Context, start int, limit int) (int, model.Metadata, error)
 	GetDatasetsForId(datasetId string, start int, limit int) (int, model.Metadata, error)
+	GetDatasetsForIdContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error)
 	GetDatasetsForTimeseries(timeseriesId string, start int, limit int) (int, model.Metadata, error)
+	GetDatasetsForTimeseriesContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error)
 	GetTimeseries(start int, limit int) (int, model.Metadata, error)
+	GetTimeseriesContext(ctx context.Context, start int, limit int) (int, model.Metadata, error)
 	GetTimeseriesForId(timeseriesId string, start int, limit int) (int, model.Metadata, error)
+	GetTimeseriesForIdContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error)
 	GetTimeseriesForDataset(datasetId string, start int, limit int) (int, model.Metadata, error)
+	GetTimeseriesForDatasetContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error)
 	GetDataset(datasetId string, timeseriesId string) (int, model.Record, error)
+	GetDatasetContext(ctx context.Context, datasetId string, timeseriesId string) (int, model.Record, error)
+}
+
+// SearchClient searches the metadata of datasets and timeseries.
+type SearchClient interface {
 	Search(term string, start int, limit int) (int, model.Metadata, error)
-	getMetadata(path string, params map[string]string) (int, model.Metadata, error)
+	SearchContext(ctx context.Context, term string, start int, limit int) (int, model.Metadata, error)
+}
 
-	GetData(datasetId string, timeseriesId string) (int, model.Data, error)
+// DataClient fetches the observations of a timeseries.
+type DataClient interface {
+	GetData(datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error)
+	GetDataContext(ctx context.Context, datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error)
 }
 
-func NewApiClient() ApiClient {
-	logging.Init(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+func NewApiClient(opts ...Option) ApiClient {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.coalescer == nil {
+		cfg.coalescer = NewCoalescer()
+	}
+
+	if cfg.logger == nil {
+		cfg.logger = logging.NewJSON(os.Stdout, logging.LevelInfo)
+		cfg.httpOptions = append(cfg.httpOptions, http.WithLogger(cfg.logger))
+	}
+
+	var c ApiClient = &apiService{
+		httpClient: http.NewHttpClient(cfg.httpOptions...),
+		logger:     cfg.logger,
+		cache:      cfg.cache,
+		coalescer:  cfg.coalescer,
+		metrics:    cfg.metrics,
+	}
+
+	if cfg.metrics != nil {
+		c = measure(cfg.metrics)(c)
+	}
 
-	return &apiService{httpClient: http.NewHttpClient()}
+	for i := len(cfg.middlewares) - 1; i >= 0; i-- {
+		c = cfg.middlewares[i](c)
+	}
+
+	return c
 }
 
 type apiService struct {
 	httpClient http.HttpClient
+	logger     logging.Logger
+	cache      *cache.Cache
+	coalescer  *Coalescer
+	metrics    *metrics.Registry
 }
 
 func (s *apiService) Ping() (int, error) {
-	resp := s.httpClient.Head("/ops/ping")
-
-	if resp.Failure != nil {
-		logging.Error.Println(resp.Failure)
-
-		return 0, resp.Failure
-	}
-
-	return resp.Success.StatusCode, nil
+	return s.PingContext(context.Background())
 }
 
-func (s *apiService) Status() (int, model.Status, error) {
-	resp := s.httpClient.Get("/ops/status", nil)
+func (s *apiService) PingContext(ctx context.Context) (int, error) {
+	resp := s.httpClient.HeadContext(ctx, "/ops/ping")
 
 	if resp.Failure != nil {
-		logging.Error.Println(resp.Failure)
+		err := newFailureError("/ops/ping", resp.Failure)
+		s.logError("/ops/ping", err)
 
-		return resp.Success.StatusCode, model.Status{}, resp.Failure
+		return 0, err
 	}
 
+	// Closing the body releases the context the request ran on.
 	defer resp.Success.Body.Close()
 
-	bodyBytes, e := ioutil.ReadAll(resp.Success.Body)
-	if e != nil {
-		logging.Error.Println(e)
-		panic(e)
+	if resp.Success.StatusCode >= 300 {
+		err := newStatusError("/ops/ping", resp.Success)
+		s.logError("/ops/ping", err)
+
+		return resp.Success.StatusCode, err
 	}
 
+	return resp.Success.StatusCode, nil
+}
+
+func (s *apiService) Status() (int, model.Status, error) {
+	return s.StatusContext(context.Background())
+}
+
+func (s *apiService) StatusContext(ctx context.Context) (int, model.Status, error) {
 	var body model.Status
-	err := json.Unmarshal(bodyBytes, &body)
-	if err != nil {
-		logging.Error.Println(err)
-		panic(err)
+	statusCode, err := s.getJson(ctx, "/ops/status", nil, &body)
+	if err != nil && !IsStale(err) {
+		return statusCode, model.Status{}, err
 	}
 
-	return resp.Success.StatusCode, body, nil
+	return statusCode, body, err
 }
 
 func (s *apiService) GetDatasets(start int, limit int) (int, model.Metadata, error) {
+	return s.GetDatasetsContext(context.Background(), start, limit)
+}
+
+func (s *apiService) GetDatasetsContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata("/dataset", params)
+	return s.getMetadata(ctx, "/dataset", params)
 }
 
 func (s *apiService) GetDatasetsForId(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return s.GetDatasetsForIdContext(context.Background(), datasetId, start, limit)
+}
+
+func (s *apiService) GetDatasetsForIdContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
 	path := buildPath([]string{"/dataset/", datasetId})
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata(path, params)
+	return s.getMetadata(ctx, path, params)
 }
 
 func (s *apiService) GetDatasetsForTimeseries(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return s.GetDatasetsForTimeseriesContext(context.Background(), timeseriesId, start, limit)
+}
+
+func (s *apiService) GetDatasetsForTimeseriesContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
 	path := buildPath([]string{"/timeseries/", timeseriesId, "/dataset"})
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata(path, params)
+	return s.getMetadata(ctx, path, params)
 }
 
 func (s *apiService) GetTimeseries(start int, limit int) (int, model.Metadata, error) {
+	return s.GetTimeseriesContext(context.Background(), start, limit)
+}
+
+func (s *apiService) GetTimeseriesContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata("/timeseries", params)
+	return s.getMetadata(ctx, "/timeseries", params)
 }
 
 func (s *apiService) GetTimeseriesForId(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return s.GetTimeseriesForIdContext(context.Background(), timeseriesId, start, limit)
+}
+
+func (s *apiService) GetTimeseriesForIdContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
 	path := buildPath([]string{"/timeseries/", timeseriesId})
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata(path, params)
+	return s.getMetadata(ctx, path, params)
 }
 
 func (s *apiService) GetTimeseriesForDataset(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return s.GetTimeseriesForDatasetContext(context.Background(), datasetId, start, limit)
+}
+
+func (s *apiService) GetTimeseriesForDatasetContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
 	path := buildPath([]string{"/dataset/", datasetId, "/timeseries"})
 	params := make(map[string]string)
 	params["start"] = strconv.Itoa(start)
 	params["limit"] = strconv.Itoa(limit)
 
-	return s.getMetadata(path, params)
+	return s.getMetadata(ctx, path, params)
 }
 
 func (s *apiService) GetDataset(datasetId string, timeseriesId string) (int, model.Record, error) {
+	return s.GetDatasetContext(context.Background(), datasetId, timeseriesId)
+}
+
+func (s *apiService) GetDatasetContext(ctx context.Context, datasetId string, timeseriesId string) (int, model.Record, error) {
 	path := buildPath([]string{"/dataset/", datasetId, "/timeseries/", timeseriesId})
 
-	resp := s.httpClient.Get(path, nil)
+	var body model.Record
+	statusCode, err := s.getJson(ctx, path, nil, &body)
+	if err != nil && !IsStale(err) {
+		return statusCode, model.Record{}, err
+	}
 
-	if resp.Failure != nil {
-		logging.Error.Println(resp.Failure)
+	return statusCode, body, err
+}
+
+func (s *apiService) Search(term string, start int, limit int) (int, model.Metadata, error) {
+	return s.SearchContext(context.Background(), term, start, limit)
+}
+
+func (s *apiService) SearchContext(ctx context.Context, term string, start int, limit int) (int, model.Metadata, error) {
+	params := make(map[string]string)
+	params["q"] = term
+	params["start"] = strconv.Itoa(start)
+	params["limit"] = strconv.Itoa(limit)
+
+	return s.getMetadata(ctx, "/search", params)
+}
 
-		return resp.Success.StatusCode, model.Record{}, resp.Failure
+func (s *apiService) getMetadata(ctx context.Context, path string, params map[string]string) (int, model.Metadata, error) {
+	var body model.Metadata
+	statusCode, err := s.getJson(ctx, path, params, &body)
+	if err != nil && !IsStale(err) {
+		return statusCode, model.Metadata{}, err
 	}
 
-	defer resp.Success.Body.Close()
+	return statusCode, body, err
+}
+
+func (s *apiService) GetData(datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error) {
+	return s.GetDataContext(context.Background(), datasetId, timeseriesId, opts...)
+}
 
-	bodyBytes, e := ioutil.ReadAll(resp.Success.Body)
-	if e != nil {
-		logging.Error.Println(e)
-		panic(e)
+func (s *apiService) GetDataContext(ctx context.Context, datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error) {
+	path := buildPath([]string{"/dataset/", datasetId, "/timeseries/", timeseriesId, "/data"})
+
+	var query dataQuery
+	for _, opt := range opts {
+		opt(&query)
 	}
 
-	var body model.Record
-	err := json.Unmarshal(bodyBytes, &body)
+	from, to, err := query.bounds()
 	if err != nil {
-		logging.Error.Println(err)
-		panic(err)
+		return 0, model.Data{}, err
 	}
 
-	return resp.Success.StatusCode, body, nil
+	var body model.Data
+	statusCode, err := s.getJson(ctx, path, query.params(), &body)
+	if err != nil && !IsStale(err) {
+		return statusCode, model.Data{}, err
+	}
+
+	if len(opts) > 0 {
+		body = body.Filter(query.frequency, from, to)
+	}
+
+	return statusCode, body, err
 }
 
-func (s *apiService) Search(term string, start int, limit int) (int, model.Metadata, error) {
-	params := make(map[string]string)
-	params["q"] = term
-	params["start"] = strconv.Itoa(start)
-	params["limit"] = strconv.Itoa(limit)
+func (s *apiService) getJson(ctx context.Context, path string, params map[string]string, body interface{}) (int, error) {
+	key := cacheKey(path, params)
+	op := http.OperationForPath(path)
+
+	if s.cache != nil && s.cache.TTL(op) > 0 {
+		entry, ok := s.cache.Get(key)
 
-	return s.getMetadata("/search", params)
+		if s.metrics != nil {
+			if ok {
+				s.metrics.IncCacheHits(string(op))
+			} else {
+				s.metrics.IncCacheMisses(string(op))
+			}
+		}
+
+		if ok {
+			return entry.StatusCode, s.decode(path, entry.StatusCode, entry.Body, body)
+		}
+	}
+
+	statusCode, value, err := s.coalescer.do(ctx, key, func(ctx context.Context) (int, interface{}, error) {
+		statusCode, bodyBytes, err := s.fetch(ctx, path, params, key)
+		if bodyBytes == nil {
+			return statusCode, nil, err
+		}
+
+		return statusCode, bodyBytes, err
+	})
+
+	if err != nil && err == ctx.Err() {
+		// The caller gave up before the shared request completed.
+		err = newFailureError(path, err)
+		s.logError(path, err)
+
+		return statusCode, err
+	}
+
+	// The raw body is shared between coalesced callers, and each decodes
+	// its own copy so no two callers hold the same model.
+	if bodyBytes, ok := value.([]byte); ok {
+		if decodeErr := s.decode(path, statusCode, bodyBytes, body); decodeErr != nil {
+			return statusCode, decodeErr
+		}
+	}
+
+	return statusCode, err
 }
 
-func (s *apiService) getMetadata(path string, params map[string]string) (int, model.Metadata, error) {
-	resp := s.httpClient.Get(path, params)
+// fetch returns the body of a successful response to path, which is also
+// cached, or the cached body of a stale entry alongside a StaleError.
+func (s *apiService) fetch(ctx context.Context, path string, params map[string]string, key string) (int, []byte, error) {
+	reqCtx := ctx
+	if s.cache != nil {
+		if validators, ok := s.cache.Validators(key); ok {
+			reqCtx = http.WithValidators(ctx, validators)
+		}
+	}
+
+	resp := s.httpClient.GetContext(reqCtx, path, params)
+
+	if resp.Failure == nil && resp.Success.StatusCode == nethttp.StatusNotModified {
+		resp.Success.Body.Close()
+
+		if s.cache != nil {
+			if entry, ok := s.cache.Revalidate(http.OperationForPath(path), key); ok {
+				return entry.StatusCode, entry.Body, nil
+			}
+		}
+
+		// The cached value was evicted after its validators were read.
+		resp = s.httpClient.GetContext(ctx, path, params)
+	}
 
 	if resp.Failure != nil {
-		logging.Error.Println(resp.Failure)
+		err := newFailureError(path, resp.Failure)
+		s.logError(path, err)
 
-		return resp.Success.StatusCode, model.Metadata{}, resp.Failure
+		if s.cache != nil && IsCircuitOpen(err) {
+			if entry, age, ok := s.cache.GetStale(key); ok {
+				return entry.StatusCode, entry.Body, &StaleError{Path: path, Age: age, Err: err}
+			}
+		}
+
+		return resp.Success.StatusCode, nil, err
 	}
 
 	defer resp.Success.Body.Close()
 
-	bodyBytes, e := ioutil.ReadAll(resp.Success.Body)
-	if e != nil {
-		logging.Error.Println(e)
-		panic(e)
+	if resp.Success.StatusCode >= 300 {
+		err := newStatusError(path, resp.Success)
+		s.logError(path, err)
+
+		return resp.Success.StatusCode, nil, err
 	}
 
-	var body model.Metadata
-	err := json.Unmarshal(bodyBytes, &body)
+	bodyBytes, err := ioutil.ReadAll(resp.Success.Body)
 	if err != nil {
-		logging.Error.Println(err)
-		panic(err)
+		err = &DecodeError{Path: path, StatusCode: resp.Success.StatusCode, Err: err}
+		s.logError(path, err)
+
+		return resp.Success.StatusCode, nil, err
+	}
+
+	// A malformed body is returned so that each caller reports why it could
+	// not be decoded, but it is not cached.
+	if s.cache != nil && json.Valid(bodyBytes) {
+		s.cache.SetWithValidators(http.OperationForPath(path), key, resp.Success.StatusCode, bodyBytes, http.ValidatorsOf(resp.Success), cacheTags(path)...)
 	}
 
-	return resp.Success.StatusCode, body, nil
+	return resp.Success.StatusCode, bodyBytes, nil
 }
 
-func (s *apiService) GetData(datasetId string, timeseriesId string) (int, model.Data, error) {
-	path := buildPath([]string{"/dataset/", datasetId, "/timeseries/", timeseriesId, "/data"})
+func (s *apiService) decode(path string, statusCode int, bodyBytes []byte, body interface{}) error {
+	if err := json.Unmarshal(bodyBytes, body); err != nil {
+		err = &DecodeError{Path: path, StatusCode: statusCode, Err: err}
+		s.logError(path, err)
 
-	resp := s.httpClient.Get(path, nil)
+		return err
+	}
 
-	if resp.Failure != nil {
-		logging.Error.Println(resp.Failure)
+	return nil
+}
+
+func (s *apiService) logError(path string, err error) {
+	data := logging.Data{"path": path, "error": err}
 
-		return resp.Success.StatusCode, model.Data{}, resp.Failure
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		data["status"] = statusErr.StatusCode
 	}
 
-	defer resp.Success.Body.Close()
+	s.logger.Event(logging.LevelError, "api_error", data)
+}
 
-	bodyBytes, e := ioutil.ReadAll(resp.Success.Body)
-	if e != nil {
-		logging.Error.Println(e)
-		panic(e)
+func cacheKey(path string, params map[string]string) string {
+	if len(params) == 0 {
+		return path
 	}
 
-	var body model.Data
-	err := json.Unmarshal(bodyBytes, &body)
-	if err != nil {
-		logging.Error.Println(err)
-		panic(err)
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+
+	return path + "?" + values.Encode()
+}
+
+func cacheTags(path string) []string {
+	var tags []string
+
+	fragments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(fragments); i++ {
+		switch fragments[i] {
+		case "dataset":
+			tags = append(tags, cache.TagDataset(fragments[i+1]))
+		case "timeseries":
+			tags = append(tags, cache.TagTimeseries(fragments[i+1]))
+		}
 	}
 
-	return resp.Success.StatusCode, body, nil
+	return tags
 }
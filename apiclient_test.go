@@ -1,12 +1,17 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/ONSdigital/dp-apipoc-client/apipoctest"
+	"github.com/ONSdigital/dp-apipoc-client/cache"
 	"github.com/ONSdigital/dp-apipoc-client/model"
 	"github.com/afex/hystrix-go/hystrix"
 	"github.com/stretchr/testify/assert"
@@ -45,9 +50,69 @@ func TestPingWhenAPIServerIsAvailable(t *testing.T) {
 }
 
 func TestPingWhenAPIServerIsNotAvailable(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.Fixtures{})
+	defer server.Close()
+
+	server.Inject("", apipoctest.Fault{Latency: time.Second})
+
+	client := NewApiClient(WithBaseUrl(server.URL))
+
+	assert.Equal(t, M(0, &TransportError{Path: "/ops/ping", Err: hystrix.CircuitError{Message: "timeout"}}), M(client.Ping()))
+}
+
+func TestPingContextWhenCancelled(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://foo.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"HEAD",
+		"http://foo.com/ops/ping",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, ""), nil
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	client := NewApiClient()
 
-	assert.Equal(t, M(0, hystrix.CircuitError{Message: "timeout"}), M(client.Ping()))
+	assert.Equal(t, M(0, &TransportError{Path: "/ops/ping", Err: context.Canceled}), M(client.PingContext(ctx)))
+
+	os.Unsetenv("API_SERVER_ROOT")
+}
+
+func TestPingReleasesRequestContext(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://foo.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, statusCode := range []int{200, 503} {
+		var reqCtx context.Context
+		httpmock.RegisterResponder(
+			"HEAD",
+			"http://foo.com/ops/ping",
+			func(req *http.Request) (*http.Response, error) {
+				reqCtx = req.Context()
+				return &http.Response{StatusCode: statusCode, Body: http.NoBody, Request: req}, nil
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		client := NewApiClient()
+		client.PingContext(ctx)
+
+		assert.NotNil(t, reqCtx)
+		assert.Equal(t, context.Canceled, reqCtx.Err(), "status %d", statusCode)
+
+		cancel()
+	}
+
+	os.Unsetenv("API_SERVER_ROOT")
 }
 
 func TestStatus(t *testing.T) {
@@ -131,6 +196,56 @@ func TestStatus(t *testing.T) {
 	os.Unsetenv("API_SERVER_ROOT")
 }
 
+func TestStatusWhenServerReturnsError(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://foo.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://foo.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(503, "service unavailable"), nil
+		},
+	)
+
+	client := NewApiClient()
+
+	expectedErr := &StatusError{Path: "/ops/status", StatusCode: 503, Body: "service unavailable"}
+
+	assert.Equal(t, M3(503, model.Status{}, expectedErr), M3(client.Status()))
+
+	os.Unsetenv("API_SERVER_ROOT")
+}
+
+func TestStatusWhenResponseIsMalformed(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://foo.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://foo.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, "{not json"), nil
+		},
+	)
+
+	client := NewApiClient()
+
+	statusCode, body, err := client.Status()
+
+	var decodeErr *DecodeError
+	assert.Equal(t, 200, statusCode)
+	assert.Equal(t, model.Status{}, body)
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "/ops/status", decodeErr.Path)
+
+	os.Unsetenv("API_SERVER_ROOT")
+}
+
 func TestGetDatasets(t *testing.T) {
 	os.Setenv("API_SERVER_ROOT", "http://foo.com")
 
@@ -739,3 +854,337 @@ func TestGetData(t *testing.T) {
 
 	os.Unsetenv("API_SERVER_ROOT")
 }
+
+func TestGetDataWhenNotFound(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://foo.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/xxxx/data",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(404, ""), nil
+		},
+	)
+
+	client := NewApiClient()
+
+	statusCode, body, err := client.GetData("ukea", "xxxx")
+
+	assert.Equal(t, 404, statusCode)
+	assert.Equal(t, model.Data{}, body)
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsTimeout(err))
+	assert.False(t, IsCircuitOpen(err))
+
+	os.Unsetenv("API_SERVER_ROOT")
+}
+
+func TestGetDataWithCache(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/cpcm/data",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, `{"type": "timeseries"}`), nil
+		},
+	)
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/timeseries/cpcm/dataset?limit=10&start=0",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, `{"totalItems": 1}`), nil
+		},
+	)
+
+	c := cache.New(cache.Config{})
+
+	client := NewApiClient(WithBaseUrl("http://foo.com"), WithTransport(transport), WithCache(c))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, M3(200, model.Data{DataType: "timeseries"}, nil), M3(client.GetData("ukea", "cpcm")))
+		assert.Equal(t, M3(200, model.Metadata{TotalItems: 1}, nil), M3(client.GetDatasetsForTimeseries("cpcm", 0, 10)))
+	}
+
+	assert.Equal(t, 2, transport.GetTotalCallCount())
+	assert.Equal(t, cache.Stats{Hits: 4, Misses: 2, Entries: 2}, c.Stats())
+
+	c.InvalidateTimeseries("CPCM")
+
+	assert.Equal(t, M3(200, model.Data{DataType: "timeseries"}, nil), M3(client.GetData("ukea", "cpcm")))
+	assert.Equal(t, 3, transport.GetTotalCallCount())
+}
+
+func TestCachedResponseDecodedAsAnotherType(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/abmi/data",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, `{"type": "timeseries", "uri": "/abmi"}`), nil
+		},
+	)
+
+	client := NewApiClient(WithBaseUrl("http://foo.com"), WithTransport(transport), WithCache(cache.New(cache.Config{})))
+
+	assert.Equal(t, M3(200, model.Data{DataType: "timeseries", DataUri: "/abmi"}, nil), M3(client.GetData("ukea", "abmi")))
+
+	// An id containing a slash requests the same path, and so the same
+	// cache entry, as GetData.
+	assert.NotPanics(t, func() {
+		statusCode, record, err := client.GetDataset("ukea", "abmi/data")
+
+		assert.Equal(t, 200, statusCode)
+		assert.Equal(t, "timeseries", record.RecordType)
+		assert.Nil(t, err)
+	})
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+}
+
+func TestGetDataServesStaleWhenCircuitOpen(t *testing.T) {
+	available := true
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/cpcm/data",
+		func(req *http.Request) (*http.Response, error) {
+			if available {
+				return httpmock.NewStringResponse(200, `{"type": "timeseries"}`), nil
+			}
+			return nil, errors.New("connection refused")
+		},
+	)
+
+	c := cache.New(cache.Config{
+		TTLs:     map[Operation]time.Duration{OperationData: time.Nanosecond},
+		MaxStale: time.Hour,
+	})
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithCache(c),
+		WithHystrixConfig(hystrix.CommandConfig{
+			Timeout:                1000,
+			RequestVolumeThreshold: 1,
+			ErrorPercentThreshold:  1,
+			SleepWindow:            60000,
+		}),
+	)
+
+	assert.Equal(t, M3(200, model.Data{DataType: "timeseries"}, nil), M3(client.GetData("ukea", "cpcm")))
+
+	available = false
+
+	var (
+		statusCode int
+		body       model.Data
+		err        error
+	)
+	for i := 0; i < 100 && !IsStale(err); i++ {
+		time.Sleep(10 * time.Millisecond)
+		statusCode, body, err = client.GetData("ukea", "cpcm")
+	}
+
+	var staleErr *StaleError
+	if !assert.True(t, errors.As(err, &staleErr)) {
+		return
+	}
+	assert.True(t, IsCircuitOpen(err))
+	assert.True(t, staleErr.Age > 0)
+	assert.Equal(t, 200, statusCode)
+	assert.Equal(t, model.Data{DataType: "timeseries"}, body)
+}
+
+func TestGetDataRevalidatesExpiredCacheEntries(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/cpcm/data",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				return httpmock.NewStringResponse(304, ""), nil
+			}
+
+			resp := httpmock.NewStringResponse(200, `{"type": "timeseries"}`)
+			resp.Header.Set("ETag", `"v1"`)
+			return resp, nil
+		},
+	)
+
+	c := cache.New(cache.Config{
+		TTLs: map[Operation]time.Duration{OperationData: time.Nanosecond},
+	})
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithCache(c),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 1000}),
+	)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, M3(200, model.Data{DataType: "timeseries"}, nil), M3(client.GetData("ukea", "cpcm")))
+	}
+
+	assert.Equal(t, 3, transport.GetTotalCallCount())
+	assert.Equal(t, uint64(2), c.Stats().Revalidations)
+
+	c.Purge()
+
+	assert.Equal(t, M3(200, model.Data{DataType: "timeseries"}, nil), M3(client.GetData("ukea", "cpcm")))
+	assert.Equal(t, 4, transport.GetTotalCallCount())
+}
+
+func TestUncachedStatusIsRequestedOnce(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get("If-None-Match")) > 0 {
+				return httpmock.NewStringResponse(304, ""), nil
+			}
+
+			resp := httpmock.NewStringResponse(200, `{"status": "OK"}`)
+			resp.Header.Set("ETag", `"v1"`)
+			return resp, nil
+		},
+	)
+
+	c := cache.New(cache.Config{})
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithCache(c),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 1000}),
+	)
+
+	for i := 1; i <= 3; i++ {
+		statusCode, _, err := client.Status()
+
+		assert.Equal(t, 200, statusCode)
+		assert.Nil(t, err)
+		assert.Equal(t, i, transport.GetTotalCallCount())
+	}
+
+	assert.Equal(t, uint64(0), c.Stats().Misses)
+}
+
+func TestGetDataCoalescesConcurrentCalls(t *testing.T) {
+	release := make(chan struct{})
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://foo.com/dataset/ukea/timeseries/cpcm/data",
+		func(req *http.Request) (*http.Response, error) {
+			<-release
+			return httpmock.NewStringResponse(200, `{"type": "timeseries", "months": [{"date": "2016 JAN", "value": "1"}]}`), nil
+		},
+	)
+
+	coalescer := NewCoalescer()
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithCoalescer(coalescer),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 5000}),
+	)
+
+	results := make([]model.Data, 8)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			statusCode, data, err := client.GetData("ukea", "cpcm")
+			assert.Equal(t, 200, statusCode)
+			assert.Nil(t, err)
+			results[i] = data
+		}(i)
+	}
+
+	for coalescer.Coalesced() < 7 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+	assert.Equal(t, uint64(7), coalescer.Coalesced())
+
+	// Each caller decodes its own copy, so none can modify another's.
+	months := make(map[*[]model.Period]bool)
+	for _, data := range results {
+		assert.Equal(t, "timeseries", data.DataType)
+		assert.Equal(t, []model.Period{{PeriodDate: "2016 JAN", Value: "1"}}, *data.Months)
+		months[data.Months] = true
+	}
+	assert.Len(t, months, len(results))
+}
+
+func TestGetDataWithRangeAndFrequency(t *testing.T) {
+	var query string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.RawQuery
+
+		return httpmock.NewStringResponse(200, `{
+			"years": [{"date": "2015", "value": "9"}, {"date": "2016", "value": "10"}],
+			"quarters": [
+				{"date": "2015 Q4", "value": "1"},
+				{"date": "2016 Q1", "value": "2"},
+				{"date": "2016 Q2", "value": "3"},
+				{"date": "2016 Q3", "value": "4"}
+			],
+			"months": []
+		}`), nil
+	})
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 1000}),
+	)
+
+	statusCode, body, err := client.GetData("ukea", "cpcm", DataFrom("2016 Q1"), DataTo("2016 Q2"), DataFrequency(model.Quarterly))
+
+	assert.Equal(t, 200, statusCode)
+	assert.Nil(t, err)
+	assert.Equal(t, "frequency=quarterly&from=2016+Q1&to=2016+Q2", query)
+	assert.Equal(t, []model.Period{}, *body.Years)
+	assert.Equal(t, []model.Period{{PeriodDate: "2016 Q1", Value: "2"}, {PeriodDate: "2016 Q2", Value: "3"}}, *body.Quarters)
+	assert.Equal(t, []model.Period{}, *body.Months)
+}
+
+func TestGetDataWithInvalidRange(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	client := NewApiClient(WithBaseUrl("http://foo.com"), WithTransport(transport))
+
+	statusCode, body, err := client.GetData("ukea", "cpcm", DataFrom("last year"))
+
+	var periodErr *PeriodError
+	assert.Equal(t, 0, statusCode)
+	assert.Equal(t, model.Data{}, body)
+	assert.True(t, errors.As(err, &periodErr))
+	assert.Equal(t, "from", periodErr.Option)
+	assert.Equal(t, "last year", periodErr.Period)
+	assert.Equal(t, 0, transport.GetTotalCallCount())
+}
+
+func TestCacheKeyIsIndependentOfParamOrder(t *testing.T) {
+	params := map[string]string{"start": "0", "limit": "10", "q": "cpi"}
+
+	assert.Equal(t, "/search?limit=10&q=cpi&start=0", cacheKey("/search", params))
+	assert.Equal(t, "/ops/status", cacheKey("/ops/status", nil))
+}
@@ -0,0 +1,45 @@
+package apipoctest
+
+import "github.com/ONSdigital/dp-apipoc-client/model"
+
+// DefaultFixtures returns a small fixture set: the UKEA dataset holding the
+// ABMI and CPCM timeseries, with annual and quarterly data for ABMI and
+// quarterly data for CPCM.
+func DefaultFixtures() Fixtures {
+	healthy := &model.Dependency{
+		Elasticsearch: &model.Elastic{Status: "OK", Code: 200},
+		Website:       &model.Website{Status: "OK", Code: 200},
+	}
+
+	ukea := &model.Description{Title: "UK Economic Accounts", DatasetId: "UKEA"}
+	abmi := &model.Description{Title: "Gross Domestic Product: chained volume measures", DatasetId: "UKEA", CDID: "ABMI", DataUnit: "m", PreUnit: "£"}
+	cpcm := &model.Description{Title: "Households: final consumption expenditure", DatasetId: "UKEA", CDID: "CPCM", DataUnit: "m", PreUnit: "£"}
+
+	abmiYears := []model.Period{
+		{PeriodDate: "2015", Value: "1872714", PeriodYear: "2015"},
+		{PeriodDate: "2016", Value: "1906700", PeriodYear: "2016"},
+	}
+	abmiQuarters := []model.Period{
+		{PeriodDate: "2016 Q1", Value: "473793", PeriodYear: "2016", Quarter: "Q1"},
+		{PeriodDate: "2016 Q2", Value: "476615", PeriodYear: "2016", Quarter: "Q2"},
+		{PeriodDate: "2016 Q3", Value: "478960", PeriodYear: "2016", Quarter: "Q3"},
+		{PeriodDate: "2016 Q4", Value: "482133", PeriodYear: "2016", Quarter: "Q4"},
+	}
+	cpcmQuarters := []model.Period{
+		{PeriodDate: "2016 Q3", Value: "323164", PeriodYear: "2016", Quarter: "Q3"},
+		{PeriodDate: "2016 Q4", Value: "x", PeriodYear: "2016", Quarter: "Q4"},
+	}
+
+	return Fixtures{
+		Status: model.Status{ApplicationName: "dp-apipoc-server", Dependencies: healthy},
+		Records: []model.Record{
+			{RecordUri: "/economy/grossdomesticproductgdp/datasets/unitedkingdomeconomicaccounts", RecordType: "dataset", Description: ukea},
+			{RecordUri: "/economy/grossdomesticproductgdp/timeseries/abmi/ukea", RecordType: "timeseries", Description: abmi},
+			{RecordUri: "/economy/grossdomesticproductgdp/timeseries/cpcm/ukea", RecordType: "timeseries", Description: cpcm},
+		},
+		Data: map[string]model.Data{
+			DataKey("ukea", "abmi"): {Years: &abmiYears, Quarters: &abmiQuarters, Description: abmi},
+			DataKey("ukea", "cpcm"): {Quarters: &cpcmQuarters, Description: cpcm},
+		},
+	}
+}
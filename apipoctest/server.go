@@ -0,0 +1,319 @@
+// Package apipoctest provides a fake API server for testing code that uses
+// the client.
+//
+//	server := apipoctest.NewServer(apipoctest.DefaultFixtures())
+//	defer server.Close()
+//
+//	api := client.NewApiClient(client.WithBaseUrl(server.URL))
+package apipoctest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// Fixtures is the data served. Records are matched on RecordType ("dataset"
+// or "timeseries") and on the DatasetId and CDID of their Description. Data
+// is keyed by DataKey.
+type Fixtures struct {
+	Status  model.Status
+	Records []model.Record
+	Data    map[string]model.Data
+}
+
+// DataKey returns the Fixtures.Data key for a timeseries in a dataset. Ids
+// are matched ignoring case, as by the API.
+func DataKey(datasetId string, timeseriesId string) string {
+	return strings.ToLower(datasetId + "/" + timeseriesId)
+}
+
+// Fault alters the response to matching requests. A non-zero StatusCode
+// replaces the response with Body; Drop closes the connection without
+// responding. Latency is applied first. Times limits how many requests are
+// affected, zero meaning all of them.
+type Fault struct {
+	Latency    time.Duration
+	StatusCode int
+	Body       string
+	Drop       bool
+	Times      int
+}
+
+type injected struct {
+	pattern string
+	fault   Fault
+	used    int
+}
+
+// Server is an httptest.Server implementing the API endpoints.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixtures Fixtures
+	faults   []*injected
+	requests int64
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func NewServer(fixtures Fixtures) *Server {
+	s := &Server{fixtures: fixtures, closed: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// Close stops the server, interrupting any injected latency.
+func (s *Server) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+
+	s.Server.Close()
+}
+
+// Inject adds a fault for requests whose path matches pattern, using
+// path.Match syntax, e.g. "/dataset/*/timeseries/*/data". An empty pattern
+// matches every request. Faults are tried in the order they were added.
+func (s *Server) Inject(pattern string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &injected{pattern: pattern, fault: fault})
+}
+
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// SetFixtures replaces the data served.
+func (s *Server) SetFixtures(fixtures Fixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fixtures = fixtures
+}
+
+// Requests returns the number of requests received.
+func (s *Server) Requests() int {
+	return int(atomic.LoadInt64(&s.requests))
+}
+
+func (s *Server) fault(urlPath string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.faults {
+		if f.fault.Times > 0 && f.used >= f.fault.Times {
+			continue
+		}
+
+		if matched, _ := path.Match(f.pattern, urlPath); matched || len(f.pattern) == 0 {
+			f.used++
+			return f.fault, true
+		}
+	}
+
+	return Fault{}, false
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&s.requests, 1)
+
+	if fault, ok := s.fault(r.URL.Path); ok {
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				return
+			case <-s.closed:
+				return
+			}
+		}
+
+		if fault.Drop {
+			if hijacker, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hijacker.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+
+		if fault.StatusCode != 0 {
+			w.WriteHeader(fault.StatusCode)
+			w.Write([]byte(fault.Body))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	fixtures := s.fixtures
+	s.mu.Unlock()
+
+	route(fixtures, w, r)
+}
+
+func route(f Fixtures, w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/ops/ping" {
+		if r.Method != http.MethodHead && r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	n := len(segments)
+
+	switch {
+	case r.URL.Path == "/ops/status":
+		writeJson(w, f.Status)
+
+	case r.URL.Path == "/search":
+		q := strings.ToLower(r.URL.Query().Get("q"))
+		writePage(w, r, f.filter(func(rec model.Record) bool {
+			d := description(rec)
+			return strings.Contains(strings.ToLower(d.Title), q) ||
+				strings.EqualFold(d.CDID, q) || strings.EqualFold(d.DatasetId, q)
+		}))
+
+	case segments[0] == "dataset" && n == 1:
+		writePage(w, r, f.filter(isType("dataset")))
+
+	case segments[0] == "dataset" && n == 2:
+		writePage(w, r, f.filter(func(rec model.Record) bool {
+			return strings.EqualFold(description(rec).DatasetId, segments[1])
+		}))
+
+	case segments[0] == "dataset" && n == 3 && segments[2] == "timeseries":
+		writePage(w, r, f.filter(func(rec model.Record) bool {
+			return isType("timeseries")(rec) && strings.EqualFold(description(rec).DatasetId, segments[1])
+		}))
+
+	case segments[0] == "dataset" && n == 4 && segments[2] == "timeseries":
+		records := f.filter(func(rec model.Record) bool {
+			return isType("timeseries")(rec) &&
+				strings.EqualFold(description(rec).DatasetId, segments[1]) &&
+				strings.EqualFold(description(rec).CDID, segments[3])
+		})
+		if len(records) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		writeJson(w, records[0])
+
+	case segments[0] == "dataset" && n == 5 && segments[2] == "timeseries" && segments[4] == "data":
+		data, ok := f.Data[DataKey(segments[1], segments[3])]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJson(w, data)
+
+	case segments[0] == "timeseries" && n == 1:
+		writePage(w, r, f.filter(isType("timeseries")))
+
+	case segments[0] == "timeseries" && n == 2:
+		writePage(w, r, f.filter(func(rec model.Record) bool {
+			return isType("timeseries")(rec) && strings.EqualFold(description(rec).CDID, segments[1])
+		}))
+
+	case segments[0] == "timeseries" && n == 3 && segments[2] == "dataset":
+		datasets := make(map[string]bool)
+		for _, rec := range f.Records {
+			if isType("timeseries")(rec) && strings.EqualFold(description(rec).CDID, segments[1]) {
+				datasets[strings.ToLower(description(rec).DatasetId)] = true
+			}
+		}
+		writePage(w, r, f.filter(func(rec model.Record) bool {
+			return isType("dataset")(rec) && datasets[strings.ToLower(description(rec).DatasetId)]
+		}))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f Fixtures) filter(keep func(model.Record) bool) []model.Record {
+	records := []model.Record{}
+	for _, rec := range f.Records {
+		if keep(rec) {
+			records = append(records, rec)
+		}
+	}
+
+	return records
+}
+
+func isType(recordType string) func(model.Record) bool {
+	return func(rec model.Record) bool {
+		return rec.RecordType == recordType
+	}
+}
+
+func description(rec model.Record) model.Description {
+	if rec.Description == nil {
+		return model.Description{}
+	}
+
+	return *rec.Description
+}
+
+// writePage applies the start and limit parameters, defaulting to 0 and 20.
+func writePage(w http.ResponseWriter, r *http.Request, records []model.Record) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	if start > len(records) {
+		start = len(records)
+	}
+
+	end := start + limit
+	if limit < 0 || end > len(records) {
+		end = len(records)
+	}
+
+	items := records[start:end]
+
+	writeJson(w, model.Metadata{
+		StartIndex:   start,
+		ItemsPerPage: limit,
+		TotalItems:   len(records),
+		Items:        &items,
+	})
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,125 @@
+package apipoctest_test
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/ONSdigital/dp-apipoc-client"
+	"github.com/ONSdigital/dp-apipoc-client/apipoctest"
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(server *apipoctest.Server, name string) client.ApiClient {
+	return client.NewApiClient(
+		client.WithBaseUrl(server.URL),
+		client.WithHystrixCommandName("apipoctest-"+name),
+		client.WithHystrixConfig(hystrix.CommandConfig{Timeout: 1000}),
+		client.WithLogger(logging.Nop()),
+	)
+}
+
+func titles(metadata model.Metadata) []string {
+	var t []string
+	for _, r := range *metadata.Items {
+		t = append(t, r.Description.Title)
+	}
+	return t
+}
+
+func TestEndpoints(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.DefaultFixtures())
+	defer server.Close()
+
+	api := newClient(server, "endpoints")
+
+	statusCode, err := api.Ping()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, statusCode)
+
+	_, status, err := api.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, "dp-apipoc-server", status.ApplicationName)
+
+	_, metadata, err := api.GetDatasets(0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UK Economic Accounts"}, titles(metadata))
+
+	_, metadata, err = api.GetTimeseriesForDataset("ukea", 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, metadata.TotalItems)
+	assert.Equal(t, []string{"Households: final consumption expenditure"}, titles(metadata))
+
+	_, metadata, err = api.GetDatasetsForTimeseries("cpcm", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UK Economic Accounts"}, titles(metadata))
+
+	_, metadata, err = api.Search("gross domestic", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, metadata.TotalItems)
+
+	_, record, err := api.GetDataset("UKEA", "ABMI")
+	assert.Nil(t, err)
+	assert.Equal(t, "ABMI", record.Description.CDID)
+
+	_, data, err := api.GetData("ukea", "abmi", client.DataFrom("2016 Q3"))
+	assert.Nil(t, err)
+	assert.Len(t, *data.Quarters, 2)
+
+	statusCode, _, err = api.GetData("ukea", "none")
+	assert.Equal(t, 404, statusCode)
+	assert.True(t, client.IsNotFound(err))
+
+	assert.Equal(t, 9, server.Requests())
+}
+
+func TestInjectStatusCode(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.DefaultFixtures())
+	defer server.Close()
+
+	server.Inject("/dataset/*/timeseries/*/data", apipoctest.Fault{StatusCode: 500, Body: "boom", Times: 1})
+
+	api := newClient(server, "status")
+
+	statusCode, _, err := api.GetData("ukea", "abmi")
+	assert.Equal(t, 500, statusCode)
+	assert.Equal(t, &client.StatusError{Path: "/dataset/ukea/timeseries/abmi/data", StatusCode: 500, Body: "boom"}, err)
+
+	_, _, err = api.GetData("ukea", "abmi")
+	assert.Nil(t, err)
+}
+
+func TestInjectLatency(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.DefaultFixtures())
+	defer server.Close()
+
+	server.Inject("", apipoctest.Fault{Latency: time.Second})
+
+	api := client.NewApiClient(
+		client.WithBaseUrl(server.URL),
+		client.WithHystrixCommandName("apipoctest-latency"),
+		client.WithHystrixConfig(hystrix.CommandConfig{Timeout: 20}),
+		client.WithLogger(logging.Nop()),
+	)
+
+	_, err := api.Ping()
+	assert.True(t, client.IsTimeout(err))
+
+	server.ClearFaults()
+
+	_, err = api.Ping()
+	assert.Nil(t, err)
+}
+
+func TestInjectDrop(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.DefaultFixtures())
+	defer server.Close()
+
+	server.Inject("/ops/*", apipoctest.Fault{Drop: true})
+
+	_, err := newClient(server, "drop").Ping()
+
+	assert.IsType(t, &client.TransportError{}, err)
+}
@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/http"
+)
+
+const (
+	DefaultMaxEntries = 1000
+	DefaultTTL        = 5 * time.Minute
+)
+
+// Config sizes a Cache. TTLs overrides TTL for individual operations; a zero
+// duration disables caching for that operation. Responses from
+// http.OperationPing and http.OperationStatus are never cached.
+//
+// MaxStale is how old an expired entry may be and still be served while the
+// circuit for its operation is open. Zero disables serving stale entries.
+type Config struct {
+	MaxEntries int
+	TTL        time.Duration
+	TTLs       map[http.Operation]time.Duration
+	MaxStale   time.Duration
+}
+
+type Stats struct {
+	Hits          uint64
+	Misses        uint64
+	StaleHits     uint64
+	Revalidations uint64
+	Evictions     uint64
+	Entries       int
+}
+
+// Entry holds a response body as received, so that every caller decodes
+// its own copy.
+type Entry struct {
+	StatusCode int
+	Body       []byte
+	Validators http.Validators
+	Stored     time.Time
+	Expires    time.Time
+}
+
+type item struct {
+	key   string
+	entry Entry
+	tags  []string
+}
+
+// Cache is an LRU cache of API responses, safe for concurrent use.
+type Cache struct {
+	mu sync.Mutex
+
+	maxEntries int
+	ttl        time.Duration
+	ttls       map[http.Operation]time.Duration
+	maxStale   time.Duration
+
+	items map[string]*list.Element
+	order *list.List
+	tags  map[string]map[string]struct{}
+	stats Stats
+	now   func() time.Time
+}
+
+func New(cfg Config) *Cache {
+	c := &Cache{
+		maxEntries: cfg.MaxEntries,
+		ttl:        cfg.TTL,
+		maxStale:   cfg.MaxStale,
+		ttls:       make(map[http.Operation]time.Duration),
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		tags:       make(map[string]map[string]struct{}),
+		now:        time.Now,
+	}
+
+	if c.maxEntries <= 0 {
+		c.maxEntries = DefaultMaxEntries
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultTTL
+	}
+	for op, ttl := range cfg.TTLs {
+		c.ttls[op] = ttl
+	}
+
+	return c
+}
+
+// TTL returns how long responses for op are cached, or zero if they are not.
+func (c *Cache) TTL(op http.Operation) time.Duration {
+	if op == http.OperationPing || op == http.OperationStatus {
+		return 0
+	}
+
+	if ttl, ok := c.ttls[op]; ok {
+		return ttl
+	}
+
+	return c.ttl
+}
+
+// Get returns the unexpired entry for key, recording a hit or a miss.
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok || !c.now().Before(elem.Value.(*item).entry.Expires) {
+		c.stats.Misses++
+		return Entry{}, false
+	}
+
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*item).entry, true
+}
+
+// GetStale returns the entry for key, expired or not, provided it was stored
+// no longer than MaxStale ago. The age of the entry is also returned.
+func (c *Cache) GetStale(key string) (Entry, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok || c.maxStale <= 0 {
+		return Entry{}, 0, false
+	}
+
+	entry := elem.Value.(*item).entry
+	age := c.now().Sub(entry.Stored)
+	if age > c.maxStale {
+		return Entry{}, 0, false
+	}
+
+	c.stats.StaleHits++
+
+	return entry, age, true
+}
+
+// Validators returns the validators of the entry for key, expired or not,
+// without recording a hit or a miss.
+func (c *Cache) Validators(key string) (http.Validators, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return http.Validators{}, false
+	}
+
+	v := elem.Value.(*item).entry.Validators
+
+	return v, !v.IsZero()
+}
+
+// Revalidate renews the entry for key, expired or not, after the API server
+// has confirmed it is unchanged.
+func (c *Cache) Revalidate(op http.Operation, key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+
+	it := elem.Value.(*item)
+	now := c.now()
+	it.entry.Stored = now
+	it.entry.Expires = now.Add(c.TTL(op))
+
+	c.stats.Revalidations++
+	c.order.MoveToFront(elem)
+
+	return it.entry, true
+}
+
+// Set stores body for key if op is cacheable. Tags are used for
+// invalidation, see TagDataset and TagTimeseries.
+func (c *Cache) Set(op http.Operation, key string, statusCode int, body []byte, tags ...string) {
+	c.SetWithValidators(op, key, statusCode, body, http.Validators{}, tags...)
+}
+
+// SetWithValidators is Set for a response that can later be revalidated
+// with validators, see Validators and Revalidate.
+func (c *Cache) SetWithValidators(op http.Operation, key string, statusCode int, body []byte, validators http.Validators, tags ...string) {
+	ttl := c.TTL(op)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entry := Entry{StatusCode: statusCode, Body: body, Validators: validators, Stored: now, Expires: now.Add(ttl)}
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+
+	c.items[key] = c.order.PushFront(&item{key: key, entry: entry, tags: tags})
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) MaxEntries() int {
+	return c.maxEntries
+}
+
+func (c *Cache) InvalidateDataset(datasetId string) {
+	c.invalidateTag(TagDataset(datasetId))
+}
+
+func (c *Cache) InvalidateTimeseries(timeseriesId string) {
+	c.invalidateTag(TagTimeseries(timeseriesId))
+}
+
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.tags = make(map[string]map[string]struct{})
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+
+	return stats
+}
+
+func (c *Cache) invalidateTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.tags[tag] {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+func (c *Cache) removeElement(elem *list.Element) {
+	it := c.order.Remove(elem).(*item)
+	delete(c.items, it.key)
+
+	for _, tag := range it.tags {
+		delete(c.tags[tag], it.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// TagDataset and TagTimeseries are case-insensitive as the API accepts ids
+// in either case.
+func TagDataset(datasetId string) string {
+	return "dataset:" + strings.ToLower(datasetId)
+}
+
+func TagTimeseries(timeseriesId string) string {
+	return "timeseries:" + strings.ToLower(timeseriesId)
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(cfg Config) (*Cache, *clock) {
+	clk := &clock{now: time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)}
+
+	c := New(cfg)
+	c.now = clk.Now
+
+	return c, clk
+}
+
+func TestGetReturnsStoredValue(t *testing.T) {
+	c, _ := newTestCache(Config{})
+
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("value"))
+
+	entry, ok := c.Get("/dataset/ukea/timeseries/cpcm/data")
+
+	assert.True(t, ok)
+	assert.Equal(t, 200, entry.StatusCode)
+	assert.Equal(t, "value", string(entry.Body))
+	assert.Equal(t, Stats{Hits: 1, Entries: 1}, c.Stats())
+}
+
+func TestGetMissesAfterTTL(t *testing.T) {
+	c, clk := newTestCache(Config{
+		TTL:  time.Minute,
+		TTLs: map[http.Operation]time.Duration{http.OperationData: time.Hour},
+	})
+
+	c.Set(http.OperationMetadata, "/timeseries/cpcm", 200, []byte("metadata"))
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("data"))
+
+	clk.now = clk.now.Add(2 * time.Minute)
+
+	_, ok := c.Get("/timeseries/cpcm")
+	assert.False(t, ok)
+
+	_, ok = c.Get("/dataset/ukea/timeseries/cpcm/data")
+	assert.True(t, ok)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Entries: 2}, c.Stats())
+}
+
+func TestSetIgnoresUncachedOperations(t *testing.T) {
+	c, _ := newTestCache(Config{
+		TTLs: map[http.Operation]time.Duration{http.OperationSearch: 0},
+	})
+
+	c.Set(http.OperationSearch, "/search?q=cpi", 200, []byte("search"))
+	c.Set(http.OperationStatus, "/ops/status", 200, []byte("status"))
+
+	assert.Equal(t, 0, c.Stats().Entries)
+}
+
+func TestSetEvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(Config{MaxEntries: 2})
+
+	c.Set(http.OperationMetadata, "a", 200, []byte("a"))
+	c.Set(http.OperationMetadata, "b", 200, []byte("b"))
+	c.Get("a")
+	c.Set(http.OperationMetadata, "c", 200, []byte("c"))
+
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	assert.Equal(t, uint64(1), c.Stats().Evictions)
+}
+
+func TestInvalidateByDatasetAndTimeseries(t *testing.T) {
+	c, _ := newTestCache(Config{})
+
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("cpcm"), TagDataset("ukea"), TagTimeseries("cpcm"))
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/nmcu/data", 200, []byte("nmcu"), TagDataset("ukea"), TagTimeseries("nmcu"))
+	c.Set(http.OperationData, "/dataset/mm23/timeseries/d7bt/data", 200, []byte("d7bt"), TagDataset("mm23"), TagTimeseries("d7bt"))
+
+	c.InvalidateTimeseries("CPCM")
+	assert.Equal(t, 2, c.Stats().Entries)
+
+	c.InvalidateDataset("UKEA")
+	assert.Equal(t, 1, c.Stats().Entries)
+
+	_, ok := c.Get("/dataset/mm23/timeseries/d7bt/data")
+	assert.True(t, ok)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Entries)
+}
+
+func TestGetStaleWithinMaxStale(t *testing.T) {
+	c, clk := newTestCache(Config{TTL: time.Minute, MaxStale: time.Hour})
+
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("data"))
+
+	clk.now = clk.now.Add(30 * time.Minute)
+
+	_, ok := c.Get("/dataset/ukea/timeseries/cpcm/data")
+	assert.False(t, ok)
+
+	entry, age, ok := c.GetStale("/dataset/ukea/timeseries/cpcm/data")
+	assert.True(t, ok)
+	assert.Equal(t, "data", string(entry.Body))
+	assert.Equal(t, 30*time.Minute, age)
+
+	clk.now = clk.now.Add(time.Hour)
+
+	_, _, ok = c.GetStale("/dataset/ukea/timeseries/cpcm/data")
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(1), c.Stats().StaleHits)
+}
+
+func TestGetStaleDisabledByDefault(t *testing.T) {
+	c, _ := newTestCache(Config{})
+
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("data"))
+
+	_, _, ok := c.GetStale("/dataset/ukea/timeseries/cpcm/data")
+	assert.False(t, ok)
+}
+
+func TestRevalidateRenewsExpiredEntry(t *testing.T) {
+	c, clk := newTestCache(Config{TTL: time.Minute})
+
+	c.Set(http.OperationData, "/dataset/ukea/timeseries/cpcm/data", 200, []byte("data"))
+
+	clk.now = clk.now.Add(time.Hour)
+
+	entry, ok := c.Revalidate(http.OperationData, "/dataset/ukea/timeseries/cpcm/data")
+	assert.True(t, ok)
+	assert.Equal(t, "data", string(entry.Body))
+	assert.Equal(t, clk.now.Add(time.Minute), entry.Expires)
+
+	_, ok = c.Get("/dataset/ukea/timeseries/cpcm/data")
+	assert.True(t, ok)
+
+	_, ok = c.Revalidate(http.OperationData, "/missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(1), c.Stats().Revalidations)
+}
+
+func TestValidatorsAreEvictedWithTheirEntry(t *testing.T) {
+	c, clk := newTestCache(Config{MaxEntries: 1, TTL: time.Minute})
+
+	v := http.Validators{ETag: `"v1"`}
+	c.SetWithValidators(http.OperationMetadata, "a", 200, []byte("a"), v)
+	c.Set(http.OperationMetadata, "b", 200, []byte("b"))
+
+	clk.now = clk.now.Add(time.Hour)
+
+	_, ok := c.Validators("a")
+	assert.False(t, ok)
+
+	c.SetWithValidators(http.OperationMetadata, "c", 200, []byte("c"), v)
+
+	clk.now = clk.now.Add(time.Hour)
+
+	got, ok := c.Validators("c")
+	assert.True(t, ok)
+	assert.Equal(t, v, got)
+
+	c.SetWithValidators(http.OperationStatus, "/ops/status", 200, []byte("status"), v)
+	_, ok = c.Validators("/ops/status")
+	assert.False(t, ok)
+}
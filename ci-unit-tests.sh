@@ -3,8 +3,7 @@
 set -e -u -x
 
 export GOPATH=$(pwd)/project
-
-go get -u github.com/kardianos/govendor
+export GO111MODULE=off
 
 cd project/src/github.com/ONSdigital/dp-apipoc-client/
 
@@ -0,0 +1,316 @@
+// Command apipoc calls the ONS API from the command line.
+//
+//	apipoc [flags] <command> [args]
+//
+// Commands mirror client.ApiClient: ping, status, datasets [id],
+// timeseries [id], search <term>, record <dataset> <timeseries> and
+// data <dataset> <timeseries>. Flags may be given before or after the
+// command. The API server root is taken from --url or API_SERVER_ROOT.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	client "github.com/ONSdigital/dp-apipoc-client"
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+const usage = `usage: apipoc [flags] <command> [args]
+
+commands:
+  ping
+  status
+  datasets [dataset]        list datasets, or one dataset's entries
+  timeseries [timeseries]   list timeseries, or one timeseries' entries
+  search <term>
+  record <dataset> <timeseries>
+  data <dataset> <timeseries>
+
+flags:
+`
+
+var errUsage = errors.New("usage")
+
+// defaultRequestTimeout bounds each request when --timeout is not given. The
+// library's hystrix default is only meant for servers on the same network.
+const defaultRequestTimeout = 10 * time.Second
+
+type options struct {
+	url        string
+	format     string
+	timeout    time.Duration
+	verbose    bool
+	start      int
+	limit      int
+	all        bool
+	dataset    string
+	timeseries string
+	from       string
+	to         string
+	frequency  string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes one command and returns the exit status. Extra client options
+// are applied after those derived from the flags.
+func run(args []string, stdout io.Writer, stderr io.Writer, clientOpts ...client.Option) int {
+	var opts options
+
+	fs := flag.NewFlagSet("apipoc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.url, "url", "", "API server root (default $API_SERVER_ROOT)")
+	fs.StringVar(&opts.format, "format", "table", "output format: table, json or csv")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "overall timeout, e.g. 30s (default 10s per request)")
+	fs.BoolVar(&opts.verbose, "verbose", false, "log requests to stderr")
+	fs.IntVar(&opts.start, "start", 0, "index of the first record")
+	fs.IntVar(&opts.limit, "limit", 20, "records per request")
+	fs.BoolVar(&opts.all, "all", false, "fetch every page from --start onwards")
+	fs.StringVar(&opts.dataset, "dataset", "", "timeseries: list the timeseries of this dataset")
+	fs.StringVar(&opts.timeseries, "timeseries", "", "datasets: list the datasets containing this timeseries")
+	fs.StringVar(&opts.from, "from", "", "data: first period, e.g. 2015 Q1")
+	fs.StringVar(&opts.to, "to", "", "data: last period")
+	fs.StringVar(&opts.frequency, "frequency", "", "data: annual, quarterly or monthly")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	if len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	out, err := newOutput(opts.format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	api := client.NewApiClient(append(clientOptions(opts, stderr), clientOpts...)...)
+
+	err = execute(ctx, api, positional[0], positional[1:], opts, out)
+	if err == errUsage {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "apipoc:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// parse allows flags to follow positional arguments.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func clientOptions(opts options, stderr io.Writer) []client.Option {
+	logger := logging.Nop()
+	if opts.verbose {
+		logger = logging.NewJSON(stderr, logging.LevelDebug)
+	}
+
+	timeout := defaultRequestTimeout
+	if opts.timeout > 0 {
+		timeout = opts.timeout
+	}
+
+	clientOpts := []client.Option{
+		client.WithLogger(logger),
+		client.WithUserAgent("apipoc"),
+		client.WithHttpClient(&http.Client{Timeout: timeout}),
+		client.WithHystrixConfig(hystrix.CommandConfig{Timeout: int(timeout / time.Millisecond)}),
+	}
+
+	if len(opts.url) > 0 {
+		clientOpts = append(clientOpts, client.WithBaseUrl(opts.url))
+	}
+
+	return clientOpts
+}
+
+func execute(ctx context.Context, api client.ApiClient, command string, args []string, opts options, out output) error {
+	switch command {
+	case "ping":
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		statusCode, err := api.PingContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		return out.ping(statusCode)
+
+	case "status":
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		_, status, err := api.StatusContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		return out.status(status)
+
+	case "datasets":
+		switch {
+		case len(args) == 1 && len(opts.timeseries) == 0:
+			return records(ctx, bind(api.GetDatasetsForIdContext, args[0]), opts, out)
+		case len(args) == 0 && len(opts.timeseries) > 0:
+			return records(ctx, bind(api.GetDatasetsForTimeseriesContext, opts.timeseries), opts, out)
+		case len(args) == 0:
+			return records(ctx, api.GetDatasetsContext, opts, out)
+		}
+
+	case "timeseries":
+		switch {
+		case len(args) == 1 && len(opts.dataset) == 0:
+			return records(ctx, bind(api.GetTimeseriesForIdContext, args[0]), opts, out)
+		case len(args) == 0 && len(opts.dataset) > 0:
+			return records(ctx, bind(api.GetTimeseriesForDatasetContext, opts.dataset), opts, out)
+		case len(args) == 0:
+			return records(ctx, api.GetTimeseriesContext, opts, out)
+		}
+
+	case "search":
+		if len(args) == 1 {
+			return records(ctx, bind(api.SearchContext, args[0]), opts, out)
+		}
+
+	case "record":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		_, record, err := api.GetDatasetContext(ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		return out.records([]model.Record{record})
+
+	case "data":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		dataOpts, err := dataOptions(opts)
+		if err != nil {
+			return err
+		}
+
+		_, data, err := api.GetDataContext(ctx, args[0], args[1], dataOpts...)
+		if err != nil {
+			return err
+		}
+
+		return out.data(data)
+
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return errUsage
+}
+
+type idPageFunc func(ctx context.Context, id string, start int, limit int) (int, model.Metadata, error)
+
+func bind(fetch idPageFunc, id string) client.PageFunc {
+	return func(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+		return fetch(ctx, id, start, limit)
+	}
+}
+
+func records(ctx context.Context, fetch client.PageFunc, opts options, out output) error {
+	if !opts.all {
+		_, metadata, err := fetch(ctx, opts.start, opts.limit)
+		if err != nil {
+			return err
+		}
+
+		if metadata.Items == nil {
+			return out.records(nil)
+		}
+
+		return out.records(*metadata.Items)
+	}
+
+	var all []model.Record
+	for record, err := range client.All(ctx, fetch, client.WithStart(opts.start), client.WithPageSize(opts.limit), client.WithPrefetch()) {
+		if err != nil {
+			return err
+		}
+
+		all = append(all, record)
+	}
+
+	return out.records(all)
+}
+
+func dataOptions(opts options) ([]client.DataOption, error) {
+	var dataOpts []client.DataOption
+
+	if len(opts.from) > 0 {
+		dataOpts = append(dataOpts, client.DataFrom(opts.from))
+	}
+	if len(opts.to) > 0 {
+		dataOpts = append(dataOpts, client.DataTo(opts.to))
+	}
+
+	if len(opts.frequency) > 0 {
+		for _, freq := range []model.Frequency{model.Annual, model.Quarterly, model.Monthly} {
+			if strings.EqualFold(opts.frequency, freq.String()) {
+				return append(dataOpts, client.DataFrequency(freq)), nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown frequency %q", opts.frequency)
+	}
+
+	return dataOpts, nil
+}
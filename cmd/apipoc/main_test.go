@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/ONSdigital/dp-apipoc-client"
+	"github.com/ONSdigital/dp-apipoc-client/apipoctest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func testRun(transport *httpmock.MockTransport, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	code := run(append([]string{"--url", "http://foo.com"}, args...), &stdout, &stderr,
+		client.WithTransport(transport),
+		client.WithHystrixCommandName("apipoc-test-"+strings.Join(args, "-")))
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestPing(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("HEAD", "http://foo.com/ops/ping", httpmock.NewStringResponder(200, ""))
+
+	code, stdout, _ := testRun(transport, "ping")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "200\n", stdout)
+
+	code, stdout, _ = testRun(transport, "--format", "json", "ping")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "{\n  \"statusCode\": 200\n}\n", stdout)
+}
+
+func TestPingSlowServer(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.Fixtures{})
+	defer server.Close()
+
+	server.Inject("/ops/ping", apipoctest.Fault{Latency: 40 * time.Millisecond})
+
+	for _, args := range [][]string{{"ping"}, {"--timeout", "1s", "ping"}} {
+		var stdout, stderr bytes.Buffer
+
+		code := run(append([]string{"--url", server.URL}, args...), &stdout, &stderr,
+			client.WithHystrixCommandName("apipoc-test-slow-"+strings.Join(args, "-")))
+
+		assert.Equal(t, 0, code, stderr.String())
+		assert.Equal(t, "200\n", stdout.String())
+	}
+}
+
+func TestDatasetsTable(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/dataset/ukea",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "5", req.URL.Query().Get("start"))
+			assert.Equal(t, "2", req.URL.Query().Get("limit"))
+			return httpmock.NewStringResponse(200, `{"totalItems":1,"items":[{"type":"timeseries","uri":"/a","description":{"datasetId":"UKEA","cdid":"ABMI","title":"GDP"}}]}`), nil
+		})
+
+	code, stdout, stderr := testRun(transport, "datasets", "ukea", "--start", "5", "--limit", "2")
+
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "type        dataset  cdid  title  uri\n"+
+		"timeseries  UKEA     ABMI  GDP    /a\n", stdout)
+}
+
+func TestSearchAllCsv(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/search",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("start") == "0" {
+				return httpmock.NewStringResponse(200, `{"totalItems":2,"items":[{"type":"a","uri":"/a"}]}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"totalItems":2,"items":[{"type":"b","uri":"/b"}]}`), nil
+		})
+
+	code, stdout, stderr := testRun(transport, "--format", "csv", "search", "gdp", "--all", "--limit", "1")
+
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "type,dataset,cdid,title,uri\na,,,,/a\nb,,,,/b\n", stdout)
+}
+
+func TestDataCsv(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/dataset/ukea/timeseries/abmi/data",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "quarterly", req.URL.Query().Get("frequency"))
+			return httpmock.NewStringResponse(200, `{"quarters":[{"date":"2016 Q1","value":"10"}],"description":{"cdid":"ABMI","datasetId":"UKEA","title":"GDP","unit":"m","preUnit":"£"}}`), nil
+		})
+
+	code, stdout, stderr := testRun(transport, "--format", "csv", "data", "ukea", "abmi", "--frequency", "quarterly")
+
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "cdid,dataset,frequency,period,value,title,pre_unit,unit\n"+
+		"ABMI,UKEA,quarterly,2016 Q1,10,GDP,£,m\n", stdout)
+}
+
+func TestErrors(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/dataset/ukea/timeseries/abmi",
+		httpmock.NewStringResponder(404, "not found"))
+
+	code, _, stderr := testRun(transport, "record", "ukea", "abmi")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "unexpected status 404")
+
+	code, _, _ = testRun(transport, "record", "ukea")
+	assert.Equal(t, 2, code)
+
+	code, _, stderr = testRun(transport, "--format", "xml", "ping")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown format")
+
+	code, _, stderr = testRun(transport, "frobnicate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "unknown command")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/ONSdigital/dp-apipoc-client/export"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+type output interface {
+	ping(statusCode int) error
+	status(status model.Status) error
+	records(records []model.Record) error
+	data(data model.Data) error
+}
+
+func newOutput(format string, w io.Writer) (output, error) {
+	switch format {
+	case "table":
+		return tableOutput{w}, nil
+	case "json":
+		return jsonOutput{w}, nil
+	case "csv":
+		return csvOutput{w}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+var recordHeader = []string{"type", "dataset", "cdid", "title", "uri"}
+
+func recordRow(r model.Record) []string {
+	row := []string{r.RecordType, "", "", "", r.RecordUri}
+	if r.Description != nil {
+		row[1], row[2], row[3] = r.Description.DatasetId, r.Description.CDID, r.Description.Title
+	}
+
+	return row
+}
+
+var statusHeader = []string{"application", "elasticsearch", "website"}
+
+func statusRow(s model.Status) []string {
+	row := []string{s.ApplicationName, "", ""}
+	if s.Dependencies != nil {
+		if s.Dependencies.Elasticsearch != nil {
+			row[1] = s.Dependencies.Elasticsearch.Status
+		}
+		if s.Dependencies.Website != nil {
+			row[2] = s.Dependencies.Website.Status
+		}
+	}
+
+	return row
+}
+
+// series returns every frequency published in data, lowest first.
+func series(data model.Data) ([]model.Series, error) {
+	var all []model.Series
+
+	for _, freq := range []model.Frequency{model.Annual, model.Quarterly, model.Monthly} {
+		if len(data.Periods(freq)) == 0 {
+			continue
+		}
+
+		s, err := data.Series(freq)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, s)
+	}
+
+	return all, nil
+}
+
+type tableOutput struct {
+	w io.Writer
+}
+
+func (o tableOutput) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+
+	for _, row := range append([][]string{header}, rows...) {
+		for i, field := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, field)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func (o tableOutput) ping(statusCode int) error {
+	_, err := fmt.Fprintln(o.w, statusCode)
+	return err
+}
+
+func (o tableOutput) status(s model.Status) error {
+	return o.table(statusHeader, [][]string{statusRow(s)})
+}
+
+func (o tableOutput) records(records []model.Record) error {
+	rows := make([][]string, len(records))
+	for i, r := range records {
+		rows[i] = recordRow(r)
+	}
+
+	return o.table(recordHeader, rows)
+}
+
+func (o tableOutput) data(data model.Data) error {
+	all, err := series(data)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, s := range all {
+		for _, obs := range s.Observations {
+			value := obs.Raw
+			if obs.Status == model.Present {
+				value = strconv.FormatFloat(obs.Value, 'f', -1, 64)
+			}
+
+			rows = append(rows, []string{s.Frequency.String(), obs.Period(), value})
+		}
+	}
+
+	return o.table([]string{"frequency", "period", "value"}, rows)
+}
+
+type jsonOutput struct {
+	w io.Writer
+}
+
+func (o jsonOutput) encode(v interface{}) error {
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+func (o jsonOutput) ping(statusCode int) error {
+	return o.encode(map[string]int{"statusCode": statusCode})
+}
+
+func (o jsonOutput) status(s model.Status) error {
+	return o.encode(s)
+}
+
+func (o jsonOutput) records(records []model.Record) error {
+	if records == nil {
+		records = []model.Record{}
+	}
+
+	return o.encode(records)
+}
+
+func (o jsonOutput) data(data model.Data) error {
+	return o.encode(data)
+}
+
+type csvOutput struct {
+	w io.Writer
+}
+
+func (o csvOutput) write(header []string, rows [][]string) error {
+	w := csv.NewWriter(o.w)
+	w.Write(header)
+	w.WriteAll(rows)
+
+	return w.Error()
+}
+
+func (o csvOutput) ping(statusCode int) error {
+	return o.write([]string{"status_code"}, [][]string{{strconv.Itoa(statusCode)}})
+}
+
+func (o csvOutput) status(s model.Status) error {
+	return o.write(statusHeader, [][]string{statusRow(s)})
+}
+
+func (o csvOutput) records(records []model.Record) error {
+	rows := make([][]string, len(records))
+	for i, r := range records {
+		rows[i] = recordRow(r)
+	}
+
+	return o.write(recordHeader, rows)
+}
+
+func (o csvOutput) data(data model.Data) error {
+	all, err := series(data)
+	if err != nil {
+		return err
+	}
+
+	return export.WriteLong(o.w, all, export.WithTitles(), export.WithUnits())
+}
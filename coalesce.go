@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+type call struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	statusCode int
+	value      interface{}
+	err        error
+}
+
+// Coalescer shares one in-flight request, and its response body, between
+// concurrent callers asking for the same path and query parameters; each
+// caller decodes the body into its own result. Every client has one; pass
+// your own with WithCoalescer to read its counters. A Coalescer must not be
+// shared by clients with different base URLs.
+type Coalescer struct {
+	mu sync.Mutex
+
+	calls     map[string]*call
+	coalesced uint64
+}
+
+func NewCoalescer() *Coalescer {
+	return &Coalescer{calls: make(map[string]*call)}
+}
+
+// Coalesced returns how many calls have been served by joining another
+// caller's in-flight request.
+func (c *Coalescer) Coalesced() uint64 {
+	return atomic.LoadUint64(&c.coalesced)
+}
+
+// do runs fn once for all concurrent callers with the same key. fn runs on a
+// context carrying the first caller's values that is cancelled only once
+// every caller has given up; each caller returns as soon as its own ctx is
+// done.
+func (c *Coalescer) do(ctx context.Context, key string, fn func(ctx context.Context) (int, interface{}, error)) (int, interface{}, error) {
+	c.mu.Lock()
+	cl, ok := c.calls[key]
+	if ok {
+		cl.waiters++
+		c.mu.Unlock()
+		atomic.AddUint64(&c.coalesced, 1)
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cl = &call{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		c.calls[key] = cl
+		c.mu.Unlock()
+
+		go c.run(callCtx, key, cl, fn)
+	}
+
+	select {
+	case <-cl.done:
+		return cl.statusCode, cl.value, cl.err
+	case <-ctx.Done():
+		c.leave(key, cl)
+		return 0, nil, ctx.Err()
+	}
+}
+
+func (c *Coalescer) run(ctx context.Context, key string, cl *call, fn func(ctx context.Context) (int, interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			cl.err = fmt.Errorf("request for %s panicked: %v", key, r)
+		}
+
+		c.forget(key, cl)
+		cl.cancel()
+		close(cl.done)
+	}()
+
+	cl.statusCode, cl.value, cl.err = fn(ctx)
+}
+
+// leave cancels the shared request once its last caller has given up.
+func (c *Coalescer) leave(key string, cl *call) {
+	c.mu.Lock()
+	cl.waiters--
+	last := cl.waiters == 0
+	if last && c.calls[key] == cl {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+
+	if last {
+		cl.cancel()
+	}
+}
+
+func (c *Coalescer) forget(key string, cl *call) {
+	c.mu.Lock()
+	if c.calls[key] == cl {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+}
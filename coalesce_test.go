@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescerSharesInFlightCall(t *testing.T) {
+	c := NewCoalescer()
+
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, value, err := c.do(context.Background(), "/dataset/ukea/timeseries/cpcm/data", func(ctx context.Context) (int, interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 200, "data", nil
+			})
+
+			assert.Nil(t, err)
+			results[i] = value
+		}(i)
+	}
+
+	for c.Coalesced() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(4), c.Coalesced())
+	for _, value := range results {
+		assert.Equal(t, "data", value)
+	}
+}
+
+func TestCoalescerDoesNotShareCompletedCalls(t *testing.T) {
+	c := NewCoalescer()
+
+	for i := 0; i < 2; i++ {
+		statusCode, _, err := c.do(context.Background(), "/search", func(ctx context.Context) (int, interface{}, error) {
+			return 503, nil, errors.New("unavailable")
+		})
+
+		assert.Equal(t, 503, statusCode)
+		assert.EqualError(t, err, "unavailable")
+	}
+
+	assert.Equal(t, uint64(0), c.Coalesced())
+	assert.Equal(t, 0, len(c.calls))
+}
+
+func TestCoalescerOutlivesCancelledCaller(t *testing.T) {
+	c := NewCoalescer()
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, interface{}, error) {
+		select {
+		case <-release:
+			return 200, "data", ctx.Err()
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err := c.do(first, "/search", fn)
+		firstErr <- err
+	}()
+
+	for {
+		c.mu.Lock()
+		_, ok := c.calls["/search"]
+		c.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan interface{}, 1)
+	go func() {
+		_, value, err := c.do(context.Background(), "/search", fn)
+		assert.Nil(t, err)
+		second <- value
+	}()
+
+	for c.Coalesced() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-firstErr)
+
+	close(release)
+	assert.Equal(t, "data", <-second)
+}
+
+func TestCoalescerCancelsWhenEveryCallerLeaves(t *testing.T) {
+	c := NewCoalescer()
+
+	cancelled := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, _, err := c.do(ctx, "/search", func(ctx context.Context) (int, interface{}, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return 0, nil, ctx.Err()
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, <-cancelled)
+}
+
+func TestCoalescerRecoversPanics(t *testing.T) {
+	c := NewCoalescer()
+
+	_, value, err := c.do(context.Background(), "/search", func(ctx context.Context) (int, interface{}, error) {
+		panic("boom")
+	})
+
+	assert.Nil(t, value)
+	assert.EqualError(t, err, "request for /search panicked: boom")
+	assert.Equal(t, 0, len(c.calls))
+}
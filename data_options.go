@@ -0,0 +1,99 @@
+package client
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+type dataQuery struct {
+	from      string
+	to        string
+	frequency model.Frequency
+}
+
+// DataOption narrows the observations returned by GetData. The options are
+// sent to the server as the from, to and frequency query parameters and are
+// also applied to the response, so results are the same whether or not the
+// server honours them.
+type DataOption func(*dataQuery)
+
+// DataFrom keeps periods starting on or after the start of period, e.g.
+// "2015", "2015 Q2" or "2015 APR".
+func DataFrom(period string) DataOption {
+	return func(q *dataQuery) {
+		q.from = period
+	}
+}
+
+// DataTo keeps periods starting before the end of period, so the period
+// itself is included.
+func DataTo(period string) DataOption {
+	return func(q *dataQuery) {
+		q.to = period
+	}
+}
+
+// DataFrequency keeps only the years, quarters or months.
+func DataFrequency(freq model.Frequency) DataOption {
+	return func(q *dataQuery) {
+		q.frequency = freq
+	}
+}
+
+func (q dataQuery) params() map[string]string {
+	params := make(map[string]string)
+
+	if len(q.from) > 0 {
+		params["from"] = q.from
+	}
+	if len(q.to) > 0 {
+		params["to"] = q.to
+	}
+	if q.frequency != model.FrequencyUnknown {
+		params["frequency"] = q.frequency.String()
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
+
+// dataParams returns the query parameters set by opts, never nil.
+func dataParams(opts []DataOption) map[string]string {
+	var query dataQuery
+	for _, opt := range opts {
+		opt(&query)
+	}
+
+	params := query.params()
+	if params == nil {
+		params = make(map[string]string)
+	}
+
+	return params
+}
+
+func (q dataQuery) bounds() (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if len(q.from) > 0 {
+		_, start, _, err := model.ParsePeriod(q.from)
+		if err != nil {
+			return from, to, &PeriodError{Option: "from", Period: q.from, Err: err}
+		}
+		from = start
+	}
+
+	if len(q.to) > 0 {
+		_, _, end, err := model.ParsePeriod(q.to)
+		if err != nil {
+			return from, to, &PeriodError{Option: "to", Period: q.to, Err: err}
+		}
+		to = end
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+const maxBodyExcerpt = 512
+
+// TransportError is returned when a request could not be built or sent, or
+// no response was received from the API server.
+type TransportError struct {
+	Path string
+	Err  error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("transport error requesting %s: %v", e.Path, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// CircuitOpenError is returned when hystrix short-circuits a request because
+// recent calls to the API server have been failing.
+type CircuitOpenError struct {
+	Path string
+	Err  error
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open requesting %s: %v", e.Path, e.Err)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a response body could not be read or
+// unmarshalled into the expected model.
+type DecodeError struct {
+	Path       string
+	StatusCode int
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response from %s: %v", e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned when the API server responds with a non-2xx status.
+// Body holds at most the first 512 bytes of the response body.
+type StatusError struct {
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d requesting %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// StaleError accompanies a cached value served because the circuit for its
+// request was open. Age is how long ago the value was fetched and Err is the
+// CircuitOpenError that prevented a fresh request.
+type StaleError struct {
+	Path string
+	Age  time.Duration
+	Err  error
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("serving %s from cache, %s old: %v", e.Path, e.Age, e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// PeriodError is returned by GetData, before any request is made, when the
+// period given to DataFrom or DataTo cannot be parsed. Option is "from" or
+// "to".
+type PeriodError struct {
+	Option string
+	Period string
+	Err    error
+}
+
+func (e *PeriodError) Error() string {
+	return fmt.Sprintf("invalid %s period %q: %v", e.Option, e.Period, e.Err)
+}
+
+func (e *PeriodError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound reports whether err is a StatusError for a 404 response.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsTimeout reports whether err was caused by a hystrix, context or network timeout.
+func IsTimeout(err error) bool {
+	if errors.Is(err, hystrix.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsCircuitOpen reports whether err is a CircuitOpenError.
+func IsCircuitOpen(err error) bool {
+	var circuitErr *CircuitOpenError
+	return errors.As(err, &circuitErr)
+}
+
+// IsStale reports whether err is a StaleError, in which case the value
+// returned with it is usable but out of date.
+func IsStale(err error) bool {
+	var staleErr *StaleError
+	return errors.As(err, &staleErr)
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == statusCode
+}
+
+func newFailureError(path string, err error) error {
+	if err == hystrix.ErrCircuitOpen {
+		return &CircuitOpenError{Path: path, Err: err}
+	}
+
+	return &TransportError{Path: path, Err: err}
+}
+
+func newStatusError(path string, resp *http.Response) error {
+	var excerpt []byte
+	if resp.Body != nil {
+		excerpt, _ = ioutil.ReadAll(io.LimitReader(resp.Body, maxBodyExcerpt))
+	}
+
+	return &StatusError{Path: path, StatusCode: resp.StatusCode, Body: string(excerpt)}
+}
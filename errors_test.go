@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestNewFailureErrorWhenCircuitOpen(t *testing.T) {
+	err := newFailureError("/ops/ping", hystrix.ErrCircuitOpen)
+
+	assert.Equal(t, &CircuitOpenError{Path: "/ops/ping", Err: hystrix.ErrCircuitOpen}, err)
+	assert.True(t, IsCircuitOpen(err))
+	assert.False(t, IsTimeout(err))
+}
+
+func TestNewFailureErrorWhenTimedOut(t *testing.T) {
+	err := newFailureError("/ops/ping", hystrix.ErrTimeout)
+
+	assert.Equal(t, &TransportError{Path: "/ops/ping", Err: hystrix.ErrTimeout}, err)
+	assert.True(t, IsTimeout(err))
+	assert.False(t, IsCircuitOpen(err))
+}
+
+func TestNewFailureErrorWhenDeadlineExceeded(t *testing.T) {
+	err := newFailureError("/ops/ping", context.DeadlineExceeded)
+
+	assert.True(t, IsTimeout(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestNewStatusErrorTruncatesBody(t *testing.T) {
+	body := make([]byte, maxBodyExcerpt*2)
+	for i := range body {
+		body[i] = 'a'
+	}
+
+	err := newStatusError("/search", httpmock.NewBytesResponse(http.StatusNotFound, body))
+
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, maxBodyExcerpt, len(statusErr.Body))
+	assert.True(t, IsNotFound(err))
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// Suppressed is written in place of values ONS has withheld. Missing values
+// are written as empty fields.
+const Suppressed = "x"
+
+type config struct {
+	delimiter rune
+	titles    bool
+	units     bool
+}
+
+type Option func(*config)
+
+// WithDelimiter replaces the default comma, e.g. '\t' or ';'.
+func WithDelimiter(delimiter rune) Option {
+	return func(c *config) {
+		c.delimiter = delimiter
+	}
+}
+
+// WithTitles labels wide columns with each series' Description.Title rather
+// than its CDID, and adds a title column to long output.
+func WithTitles() Option {
+	return func(c *config) {
+		c.titles = true
+	}
+}
+
+// WithUnits adds pre_unit and unit columns to long output, and a second
+// header row holding each series' unit to wide output.
+func WithUnits() Option {
+	return func(c *config) {
+		c.units = true
+	}
+}
+
+func newWriter(w io.Writer, opts []Option) (*csv.Writer, *config) {
+	cfg := &config{delimiter: ','}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = cfg.delimiter
+
+	return writer, cfg
+}
+
+// WriteLong writes one row per observation with the columns cdid, dataset,
+// frequency, period and value.
+func WriteLong(w io.Writer, series []model.Series, opts ...Option) error {
+	writer, cfg := newWriter(w, opts)
+
+	header := []string{"cdid", "dataset", "frequency", "period", "value"}
+	if cfg.titles {
+		header = append(header, "title")
+	}
+	if cfg.units {
+		header = append(header, "pre_unit", "unit")
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		for _, o := range s.Observations {
+			row := []string{s.CDID, s.DatasetId, o.Frequency.String(), o.Period(), formatValue(o)}
+			if cfg.titles {
+				row = append(row, s.Title)
+			}
+			if cfg.units {
+				row = append(row, s.PreUnit, s.DataUnit)
+			}
+
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+type periodKey struct {
+	frequency model.Frequency
+	start     time.Time
+}
+
+// WriteWide writes one row per period and one column per series. Periods
+// missing from a series are left empty. Rows are ordered by period start,
+// with lower frequencies first when series of different frequencies share a
+// start.
+func WriteWide(w io.Writer, series []model.Series, opts ...Option) error {
+	writer, cfg := newWriter(w, opts)
+
+	header := []string{"period"}
+	units := []string{"unit"}
+	for _, s := range series {
+		label := s.CDID
+		if cfg.titles && len(s.Title) > 0 {
+			label = s.Title
+		}
+
+		header = append(header, label)
+		units = append(units, s.PreUnit+s.DataUnit)
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if cfg.units {
+		if err := writer.Write(units); err != nil {
+			return err
+		}
+	}
+
+	rows := make(map[periodKey][]string)
+	var keys []periodKey
+
+	for i, s := range series {
+		for _, o := range s.Observations {
+			key := periodKey{frequency: o.Frequency, start: o.Start}
+
+			row, ok := rows[key]
+			if !ok {
+				row = make([]string, len(series)+1)
+				row[0] = o.Period()
+				rows[key] = row
+				keys = append(keys, key)
+			}
+
+			row[i+1] = formatValue(o)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].start.Equal(keys[j].start) {
+			return keys[i].start.Before(keys[j].start)
+		}
+		return keys[i].frequency < keys[j].frequency
+	})
+
+	for _, key := range keys {
+		if err := writer.Write(rows[key]); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatValue(o model.Observation) string {
+	switch o.Status {
+	case model.Present:
+		return strconv.FormatFloat(o.Value, 'f', -1, 64)
+	case model.Suppressed:
+		return Suppressed
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func series(t *testing.T, cdid string, title string, periods ...model.Period) model.Series {
+	d := model.Data{
+		Quarters: &periods,
+		Description: &model.Description{
+			CDID:      cdid,
+			Title:     title,
+			DatasetId: "UKEA",
+			DataUnit:  "m",
+			PreUnit:   "£",
+		},
+	}
+
+	s, err := d.Series(model.Quarterly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func testSeries(t *testing.T) []model.Series {
+	return []model.Series{
+		series(t, "CPCM", "Net borrowing",
+			model.Period{PeriodDate: "2016 Q1", Value: "1136"},
+			model.Period{PeriodDate: "2016 Q2", Value: "x"},
+		),
+		series(t, "NMCU", "Net lending",
+			model.Period{PeriodDate: "2016 Q2", Value: "-12.5"},
+			model.Period{PeriodDate: "2016 Q3", Value: ""},
+		),
+	}
+}
+
+func TestWriteLong(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteLong(&buf, testSeries(t))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "cdid,dataset,frequency,period,value\n"+
+		"CPCM,UKEA,quarterly,2016 Q1,1136\n"+
+		"CPCM,UKEA,quarterly,2016 Q2,x\n"+
+		"NMCU,UKEA,quarterly,2016 Q2,-12.5\n"+
+		"NMCU,UKEA,quarterly,2016 Q3,\n", buf.String())
+}
+
+func TestWriteLongWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteLong(&buf, testSeries(t)[:1], WithDelimiter(';'), WithTitles(), WithUnits())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "cdid;dataset;frequency;period;value;title;pre_unit;unit\n"+
+		"CPCM;UKEA;quarterly;2016 Q1;1136;Net borrowing;£;m\n"+
+		"CPCM;UKEA;quarterly;2016 Q2;x;Net borrowing;£;m\n", buf.String())
+}
+
+func TestWriteWide(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteWide(&buf, testSeries(t))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "period,CPCM,NMCU\n"+
+		"2016 Q1,1136,\n"+
+		"2016 Q2,x,-12.5\n"+
+		"2016 Q3,,\n", buf.String())
+}
+
+func TestWriteWideWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteWide(&buf, testSeries(t), WithDelimiter('\t'), WithTitles(), WithUnits())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "period\tNet borrowing\tNet lending\n"+
+		"unit\t£m\t£m\n"+
+		"2016 Q1\t1136\t\n"+
+		"2016 Q2\tx\t-12.5\n"+
+		"2016 Q3\t\t\n", buf.String())
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Validators are the ETag and Last-Modified headers of a response, used to
+// revalidate it later with If-None-Match and If-Modified-Since.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// ValidatorsOf returns the validators of a 200 response, or none for any
+// other status.
+func ValidatorsOf(resp *http.Response) Validators {
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		return Validators{}
+	}
+
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+func (v Validators) IsZero() bool {
+	return len(v.ETag) <= 0 && len(v.LastModified) <= 0
+}
+
+type validatorsKey struct{}
+
+// WithValidators returns a context whose GET requests are sent with
+// If-None-Match and If-Modified-Since from v. Callers must hold the response
+// v was taken from and be prepared to handle a 304 Not Modified.
+func WithValidators(ctx context.Context, v Validators) context.Context {
+	if v.IsZero() {
+		return ctx
+	}
+
+	return context.WithValue(ctx, validatorsKey{}, v)
+}
+
+func applyValidators(ctx context.Context, req *http.Request) {
+	v, ok := ctx.Value(validatorsKey{}).(Validators)
+	if !ok || req.Method != "GET" {
+		return
+	}
+
+	if len(v.ETag) > 0 {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if len(v.LastModified) > 0 {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
@@ -1,72 +1,146 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
 	"github.com/ONSdigital/dp-apipoc-client/model"
 	"github.com/afex/hystrix-go/hystrix"
 )
 
 type HttpClient interface {
 	Head(path string) model.Response
+	HeadContext(ctx context.Context, path string) model.Response
 	Get(path string, params map[string]string) model.Response
+	GetContext(ctx context.Context, path string, params map[string]string) model.Response
 }
 
-func NewHttpClient() HttpClient {
-	var serverRoot string
-	serverRoot = os.Getenv("API_SERVER_ROOT")
-	if len(serverRoot) <= 0 {
-		serverRoot = "https://api.develop.onsdigital.co.uk"
+var clientCount uint64
+
+// errServerError reports a 5xx response to hystrix as a failure while the
+// response itself is still returned to the caller.
+var errServerError = errors.New("server error")
+
+// NewHttpClient registers a hystrix command per Operation; see client.WithHystrixCommandName.
+func NewHttpClient(opts ...Option) HttpClient {
+	cfg := &config{
+		baseUrl: os.Getenv("API_SERVER_ROOT"),
+		commandConfig: hystrix.CommandConfig{
+			Timeout: 20,
+		},
+		operationConfigs: make(map[Operation]hystrix.CommandConfig),
+		headers:          make(http.Header),
 	}
 
-	hystrix.ConfigureCommand("default_config", hystrix.CommandConfig{
-		Timeout: 20,
-	})
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if len(cfg.baseUrl) <= 0 {
+		cfg.baseUrl = "https://api.develop.onsdigital.co.uk"
+	}
+
+	if cfg.logger == nil {
+		cfg.logger = logging.NewJSON(os.Stdout, logging.LevelInfo)
+	}
+
+	// Each client owns its circuit so that one misbehaving client cannot
+	// trip the breaker for every other client in the process.
+	if len(cfg.commandName) <= 0 {
+		cfg.commandName = fmt.Sprintf("dp-apipoc-client-%d", atomic.AddUint64(&clientCount, 1))
+	}
+
+	commands := make(map[Operation]string)
+	for _, op := range Operations {
+		commandConfig, ok := cfg.operationConfigs[op]
+		if !ok {
+			commandConfig = cfg.commandConfig
+		}
+
+		commands[op] = fmt.Sprintf("%s-%s", cfg.commandName, op)
+		hystrix.ConfigureCommand(commands[op], commandConfig)
+
+		if cfg.metrics != nil {
+			cfg.metrics.RegisterCircuit(string(op), commands[op])
+		}
+	}
 
 	netClient := &http.Client{
 		Timeout: time.Second * 10,
 	}
+	if cfg.client != nil {
+		c := *cfg.client
+		netClient = &c
+	}
+	if cfg.transport != nil {
+		netClient.Transport = cfg.transport
+	}
+	if len(cfg.middlewares) > 0 {
+		netClient.Transport = chain(netClient.Transport, cfg.middlewares)
+	}
 
 	return &httpService{
-		apiServerUrl: serverRoot,
+		apiServerUrl: cfg.baseUrl,
 		httpClient:   netClient,
+		commands:     commands,
+		logger:       cfg.logger,
+		userAgent:    cfg.userAgent,
+		headers:      cfg.headers,
+		retryPolicy:  cfg.retryPolicy,
+		metrics:      cfg.metrics,
 	}
 }
 
 type httpService struct {
 	apiServerUrl string
 	httpClient   *http.Client
+	commands     map[Operation]string
+	logger       logging.Logger
+	userAgent    string
+	headers      http.Header
+	retryPolicy  RetryPolicy
+	metrics      *metrics.Registry
 }
 
 func (s *httpService) Head(path string) model.Response {
-	url := fmt.Sprintf("%s%s", s.apiServerUrl, path)
+	return s.HeadContext(context.Background(), path)
+}
 
-	responseChannel := make(chan model.Response)
+func (s *httpService) HeadContext(ctx context.Context, path string) model.Response {
+	url := fmt.Sprintf("%s%s", s.apiServerUrl, path)
 
-	hystrix.Go("default_config", func() error {
-		resp, err := s.httpClient.Head(url)
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		s.logger.Event(logging.LevelError, "request", logging.Data{"method": "HEAD", "path": path, "error": err})
 
-		responseChannel <- model.Response{Success: resp, Failure: err}
-		return nil
-	}, func(err error) error {
-		responseChannel <- model.Response{Success: &http.Response{}, Failure: err}
-		return nil
-	})
+		return model.Response{Success: &http.Response{}, Failure: err}
+	}
 
-	return <-responseChannel
+	return s.do(ctx, req)
 }
 
 func (s *httpService) Get(path string, params map[string]string) model.Response {
+	return s.GetContext(context.Background(), path, params)
+}
+
+func (s *httpService) GetContext(ctx context.Context, path string, params map[string]string) model.Response {
 	url := fmt.Sprintf("%s%s", s.apiServerUrl, path)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		logging.Error.Println(err)
-		os.Exit(1)
+		s.logger.Event(logging.LevelError, "request", logging.Data{"method": "GET", "path": path, "error": err})
+
+		return model.Response{Success: &http.Response{}, Failure: err}
 	}
 
 	q := req.URL.Query()
@@ -77,19 +151,237 @@ func (s *httpService) Get(path string, params map[string]string) model.Response
 	req.Header.Add("Accept", "application/json")
 	req.URL.RawQuery = q.Encode()
 
-	logging.Info.Println(req)
+	return s.do(ctx, req)
+}
+
+func (s *httpService) do(ctx context.Context, req *http.Request) model.Response {
+	if err := ctx.Err(); err != nil {
+		return model.Response{Success: &http.Response{}, Failure: err}
+	}
+
+	for key, values := range s.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if len(s.userAgent) > 0 {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
 
-	responseChannel := make(chan model.Response)
+	applyValidators(ctx, req)
 
-	hystrix.Go("default_config", func() error {
-		resp, err := s.httpClient.Do(req)
+	// Hystrix abandons a run when its timeout fires but cannot stop it, so
+	// the attempt and any retries run on a context the fallback cancels.
+	runCtx, cancel := context.WithCancel(ctx)
+	runCtx = withRequestIDSlot(runCtx)
+
+	req = req.WithContext(runCtx)
+	start := time.Now()
 
-		responseChannel <- model.Response{Success: resp, Failure: err}
+	slot := newResponseSlot()
+
+	var attempts int32
+
+	hystrix.Go(s.commands[OperationForPath(req.URL.Path)], func() error {
+		resp, err := s.send(runCtx, req, &attempts)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The caller gave up; that says nothing about the health of
+				// the API server so it must not count against the circuit.
+				slot.put(model.Response{Success: &http.Response{}, Failure: err, Attempts: int(atomic.LoadInt32(&attempts))})
+				return nil
+			}
+
+			return err
+		}
+
+		statusCode := resp.StatusCode
+		slot.put(model.Response{Success: resp, Failure: nil, Attempts: int(atomic.LoadInt32(&attempts))})
+
+		if statusCode >= 500 {
+			return errServerError
+		}
 		return nil
 	}, func(err error) error {
-		responseChannel <- model.Response{Success: &http.Response{}, Failure: err}
+		if err == errServerError {
+			return nil
+		}
+
+		s.recordFallback(OperationForPath(req.URL.Path), err)
+
+		// The run may already have delivered a response whose body the
+		// caller is reading, so only stop it if this failure took its place.
+		if slot.put(model.Response{Success: &http.Response{}, Failure: err, Attempts: int(atomic.LoadInt32(&attempts))}) {
+			cancel()
+		}
 		return nil
 	})
 
-	return <-responseChannel
+	var resp model.Response
+	select {
+	case resp = <-slot.ch:
+	case <-ctx.Done():
+		slot.abandon()
+		resp = model.Response{Success: &http.Response{}, Failure: ctx.Err()}
+	}
+
+	// The body of a successful response is read after do returns, so the
+	// run context is released when the caller closes it.
+	if resp.Failure == nil && resp.Success.Body != nil {
+		resp.Success.Body = &cancelOnClose{ReadCloser: resp.Success.Body, cancel: cancel}
+	} else {
+		cancel()
+	}
+
+	s.logRequest(req, resp, time.Since(start))
+
+	return resp
+}
+
+// responseSlot hands the first response from a hystrix command to do. Both
+// the run and the fallback may produce one, and either may arrive after do
+// has given up waiting; bodies of responses nobody will read are closed so
+// their connections are released.
+type responseSlot struct {
+	sync.Mutex
+	ch        chan model.Response
+	filled    bool
+	abandoned bool
+}
+
+func newResponseSlot() *responseSlot {
+	return &responseSlot{ch: make(chan model.Response, 1)}
+}
+
+// put reports whether resp was the response delivered to do.
+func (s *responseSlot) put(resp model.Response) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.filled || s.abandoned {
+		discard(resp)
+		return false
+	}
+
+	s.filled = true
+	s.ch <- resp
+	return true
+}
+
+// abandon discards any response already delivered and every later one.
+func (s *responseSlot) abandon() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.abandoned = true
+
+	select {
+	case resp := <-s.ch:
+		discard(resp)
+	default:
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func discard(resp model.Response) {
+	if resp.Success != nil && resp.Success.Body != nil {
+		resp.Success.Body.Close()
+	}
+}
+
+func (s *httpService) recordFallback(op Operation, err error) {
+	if s.metrics == nil {
+		return
+	}
+
+	s.metrics.IncFallbacks(string(op))
+
+	switch err {
+	case hystrix.ErrTimeout:
+		s.metrics.IncTimeouts(string(op))
+	case hystrix.ErrCircuitOpen:
+		s.metrics.IncShortCircuits(string(op))
+	}
+}
+
+func (s *httpService) logRequest(req *http.Request, resp model.Response, duration time.Duration) {
+	params := make(map[string]string)
+	for key, values := range req.URL.Query() {
+		params[key] = strings.Join(values, ",")
+	}
+
+	command := s.commands[OperationForPath(req.URL.Path)]
+	circuit := "closed"
+	if cb, _, err := hystrix.GetCircuit(command); err == nil && cb.IsOpen() {
+		circuit = "open"
+	}
+
+	data := logging.Data{
+		"method":   req.Method,
+		"path":     req.URL.Path,
+		"params":   params,
+		"duration": duration,
+		"attempts": resp.Attempts,
+		"command":  command,
+		"circuit":  circuit,
+	}
+
+	if id := requestIDOf(req); len(id) > 0 {
+		data["request_id"] = id
+	}
+
+	if resp.Failure != nil {
+		data["error"] = resp.Failure
+		s.logger.Event(logging.LevelError, "request", data)
+		return
+	}
+
+	data["status"] = resp.Success.StatusCode
+	s.logger.Event(logging.LevelDebug, "request", data)
+}
+
+func (s *httpService) send(ctx context.Context, req *http.Request, attempts *int32) (*http.Response, error) {
+	for {
+		attempt := int(atomic.AddInt32(attempts, 1))
+
+		resp, err := s.httpClient.Do(req)
+		if ctx.Err() != nil || s.retryPolicy == nil {
+			return resp, err
+		}
+
+		delay, retry := s.retryPolicy.Retry(attempt, req, resp, err)
+		if !retry {
+			return resp, err
+		}
+
+		data := logging.Data{"method": req.Method, "path": req.URL.Path, "attempt": attempt, "delay": delay}
+		if id := requestIDOf(req); len(id) > 0 {
+			data["request_id"] = id
+		}
+		if err != nil {
+			data["error"] = err
+		} else {
+			data["status"] = resp.StatusCode
+			resp.Body.Close()
+		}
+		s.logger.Event(logging.LevelWarn, "retry", data)
+
+		if s.metrics != nil {
+			s.metrics.IncRetries(string(OperationForPath(req.URL.Path)))
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
 }
@@ -1,14 +1,21 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ONSdigital/dp-apipoc-client/apipoctest"
 	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
+	"github.com/ONSdigital/dp-apipoc-client/model"
 	"github.com/afex/hystrix-go/hystrix"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/jarcoal/httpmock.v1"
@@ -39,7 +46,12 @@ func TestHeadWhenAPIServerIsAvailable(t *testing.T) {
 }
 
 func TestHeadWhenAPIServerIsNotAvailable(t *testing.T) {
-	os.Setenv("API_SERVER_ROOT", "http://bah.com")
+	server := apipoctest.NewServer(apipoctest.Fixtures{})
+	defer server.Close()
+
+	server.Inject("", apipoctest.Fault{Latency: time.Second})
+
+	os.Setenv("API_SERVER_ROOT", server.URL)
 
 	logging.Init(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
 
@@ -176,7 +188,79 @@ func TestGetWithQueryParamsWhenAPIServerIsAvailable(t *testing.T) {
 	os.Unsetenv("API_SERVER_ROOT")
 }
 
+func TestGetContextWhenCancelled(t *testing.T) {
+	os.Setenv("API_SERVER_ROOT", "http://bah.com")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://bah.com/ops/test",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, "{}"), nil
+		},
+	)
+
+	logging.Init(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewHttpClient()
+
+	response := client.GetContext(ctx, "/ops/test", nil)
+
+	assert.Equal(t, context.Canceled, response.Failure)
+	assert.Equal(t, 0, response.Success.StatusCode)
+
+	os.Unsetenv("API_SERVER_ROOT")
+}
+
+type closeRecorder struct {
+	closed bool
+}
+
+func (c *closeRecorder) Read(p []byte) (int, error) { return 0, io.EOF }
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestAbandonedResponsesAreClosed(t *testing.T) {
+	early, late := &closeRecorder{}, &closeRecorder{}
+
+	slot := newResponseSlot()
+	assert.True(t, slot.put(model.Response{Success: &http.Response{Body: early}}))
+	slot.abandon()
+	assert.False(t, slot.put(model.Response{Success: &http.Response{Body: late}}))
+
+	assert.True(t, early.closed)
+	assert.True(t, late.closed)
+}
+
+func TestOnlyFirstResponseIsDelivered(t *testing.T) {
+	first, second := &closeRecorder{}, &closeRecorder{}
+
+	slot := newResponseSlot()
+	assert.True(t, slot.put(model.Response{Success: &http.Response{Body: first}}))
+	assert.False(t, slot.put(model.Response{Success: &http.Response{Body: second}}))
+
+	resp := <-slot.ch
+	assert.Equal(t, first, resp.Success.Body)
+	assert.False(t, first.closed)
+	assert.True(t, second.closed)
+}
+
 func TestGetWhenAPIServerIsNotAvailable(t *testing.T) {
+	server := apipoctest.NewServer(apipoctest.Fixtures{})
+	defer server.Close()
+
+	server.Inject("", apipoctest.Fault{Latency: time.Second})
+
+	os.Setenv("API_SERVER_ROOT", server.URL)
+
 	logging.Init(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
 
 	expectedFailure := hystrix.CircuitError{Message: "timeout"}
@@ -209,3 +293,329 @@ func TestPingWhenEnvironmentVariableIsNotSet(t *testing.T) {
 	assert.Equal(t, nil, response.Failure)
 	assert.Equal(t, 200, response.Success.StatusCode)
 }
+
+func TestClientsDoNotShareCircuits(t *testing.T) {
+	staging := NewHttpClient(WithCommandConfig(hystrix.CommandConfig{Timeout: 50})).(*httpService)
+	production := NewHttpClient(WithCommandConfig(hystrix.CommandConfig{Timeout: 500})).(*httpService)
+
+	assert.NotEqual(t, staging.commands[OperationPing], production.commands[OperationPing])
+
+	settings := hystrix.GetCircuitSettings()
+
+	assert.Equal(t, 50*time.Millisecond, settings[staging.commands[OperationPing]].Timeout)
+	assert.Equal(t, 500*time.Millisecond, settings[production.commands[OperationPing]].Timeout)
+}
+
+func TestClientsWithSameCommandNameShareCircuit(t *testing.T) {
+	a := NewHttpClient(WithCommandName("shared-circuit")).(*httpService)
+	b := NewHttpClient(WithCommandName("shared-circuit")).(*httpService)
+
+	assert.Equal(t, "shared-circuit-data", a.commands[OperationData])
+	assert.Equal(t, a.commands, b.commands)
+}
+
+func TestOperationsHaveIndependentCircuits(t *testing.T) {
+	client := NewHttpClient(
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 100}),
+		WithOperationConfig(OperationSearch, hystrix.CommandConfig{
+			Timeout:                2000,
+			MaxConcurrentRequests:  5,
+			ErrorPercentThreshold:  25,
+			SleepWindow:            3000,
+			RequestVolumeThreshold: 50,
+		}),
+	).(*httpService)
+
+	settings := hystrix.GetCircuitSettings()
+
+	search := settings[client.commands[OperationSearch]]
+	assert.Equal(t, 2000*time.Millisecond, search.Timeout)
+	assert.Equal(t, 5, search.MaxConcurrentRequests)
+	assert.Equal(t, 25, search.ErrorPercentThreshold)
+	assert.Equal(t, 3000*time.Millisecond, search.SleepWindow)
+	assert.Equal(t, uint64(50), search.RequestVolumeThreshold)
+
+	for _, op := range []Operation{OperationPing, OperationStatus, OperationMetadata, OperationData} {
+		assert.Equal(t, 100*time.Millisecond, settings[client.commands[op]].Timeout)
+	}
+}
+
+func TestOperationForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		op   Operation
+	}{
+		{"/ops/ping", OperationPing},
+		{"/ops/status", OperationStatus},
+		{"/search", OperationSearch},
+		{"/dataset/ukea/timeseries/cpcm/data", OperationData},
+		{"/dataset/data/timeseries/cpcm/data", OperationData},
+		{"/dataset/ukea/timeseries/data/data", OperationData},
+		{"/dataset/ukea/timeseries/cpcm", OperationMetadata},
+		{"/dataset/ukea/timeseries/data", OperationMetadata},
+		{"/dataset/data", OperationMetadata},
+		{"/dataset/data/timeseries", OperationMetadata},
+		{"/timeseries", OperationMetadata},
+		{"/timeseries/data", OperationMetadata},
+		{"/timeseries/data/dataset", OperationMetadata},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.op, OperationForPath(test.path), test.path)
+	}
+}
+
+func TestGetRetriesTransientFailures(t *testing.T) {
+	calls := 0
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://bah.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls < 3 {
+				return httpmock.NewStringResponse(502, ""), nil
+			}
+			return httpmock.NewStringResponse(200, "{}"), nil
+		},
+	)
+
+	policy := NewBackoffPolicy(3)
+	policy.BaseDelay = time.Millisecond
+
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(transport),
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 1000}),
+		WithRetryPolicy(policy),
+	)
+
+	response := client.Get("/ops/status", nil)
+
+	assert.Equal(t, nil, response.Failure)
+	assert.Equal(t, 200, response.Success.StatusCode)
+	assert.Equal(t, 3, response.Attempts)
+}
+
+func TestTimeoutCancelsInFlightRequest(t *testing.T) {
+	cancelled := make(chan error, 1)
+
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			cancelled <- req.Context().Err()
+			return nil, req.Context().Err()
+		})),
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 20}),
+	)
+
+	response := client.Get("/ops/status", nil)
+	assert.Equal(t, hystrix.ErrTimeout, response.Failure)
+
+	select {
+	case err := <-cancelled:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("request still running after the hystrix timeout")
+	}
+}
+
+func TestGetWithoutRetryPolicyMakesOneAttempt(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://bah.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(502, ""), nil
+		},
+	)
+
+	client := NewHttpClient(WithBaseUrl("http://bah.com"), WithTransport(transport))
+
+	response := client.Get("/ops/status", nil)
+
+	assert.Equal(t, 502, response.Success.StatusCode)
+	assert.Equal(t, 1, response.Attempts)
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+}
+
+func TestGetWithValidators(t *testing.T) {
+	var received []http.Header
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"GET",
+		"http://bah.com/timeseries/cpcm",
+		func(req *http.Request) (*http.Response, error) {
+			received = append(received, req.Header)
+
+			resp := httpmock.NewStringResponse(200, "{}")
+			resp.Header.Set("ETag", `"v1"`)
+			resp.Header.Set("Last-Modified", "Fri, 23 Dec 2016 00:00:00 GMT")
+			return resp, nil
+		},
+	)
+
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(transport),
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 1000}),
+	)
+
+	first := client.Get("/timeseries/cpcm", nil)
+	validators := ValidatorsOf(first.Success)
+	client.GetContext(WithValidators(context.Background(), validators), "/timeseries/cpcm", nil)
+	client.Get("/timeseries/cpcm", nil)
+
+	assert.Equal(t, Validators{ETag: `"v1"`, LastModified: "Fri, 23 Dec 2016 00:00:00 GMT"}, validators)
+	assert.Equal(t, 3, len(received))
+	assert.Equal(t, "", received[0].Get("If-None-Match"))
+	assert.Equal(t, `"v1"`, received[1].Get("If-None-Match"))
+	assert.Equal(t, "Fri, 23 Dec 2016 00:00:00 GMT", received[1].Get("If-Modified-Since"))
+	assert.Equal(t, "", received[2].Get("If-None-Match"))
+}
+
+func TestValidatorsOfIgnoresOtherStatuses(t *testing.T) {
+	resp := httpmock.NewStringResponse(404, "")
+	resp.Header.Set("ETag", `"v1"`)
+
+	assert.True(t, ValidatorsOf(resp).IsZero())
+}
+
+type recordingLogger struct {
+	events []logging.Data
+	names  []string
+	levels []logging.Level
+}
+
+func (l *recordingLogger) Event(level logging.Level, name string, data logging.Data) {
+	l.levels = append(l.levels, level)
+	l.names = append(l.names, name)
+	l.events = append(l.events, data)
+}
+
+func TestRequestEvents(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	calls := 0
+	transport.RegisterResponder("GET", "http://bah.com/dataset",
+		func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return httpmock.NewStringResponse(503, ""), nil
+			}
+			return httpmock.NewStringResponse(200, "{}"), nil
+		})
+
+	logger := &recordingLogger{}
+	policy := NewBackoffPolicy(2)
+	policy.BaseDelay = time.Millisecond
+
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(transport),
+		WithCommandName("request-events"),
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 1000}),
+		WithRetryPolicy(policy),
+		WithLogger(logger),
+		WithHeader("X-Request-Id", "abc"),
+	)
+
+	response := client.Get("/dataset", map[string]string{"limit": "10"})
+
+	assert.Nil(t, response.Failure)
+	assert.Equal(t, []string{"retry", "request"}, logger.names)
+	assert.Equal(t, []logging.Level{logging.LevelWarn, logging.LevelDebug}, logger.levels)
+	assert.Equal(t, 503, logger.events[0]["status"])
+	assert.Equal(t, 1, logger.events[0]["attempt"])
+
+	event := logger.events[1]
+	assert.Equal(t, "GET", event["method"])
+	assert.Equal(t, "/dataset", event["path"])
+	assert.Equal(t, map[string]string{"limit": "10"}, event["params"])
+	assert.Equal(t, 200, event["status"])
+	assert.Equal(t, 2, event["attempts"])
+	assert.Equal(t, "closed", event["circuit"])
+	assert.Equal(t, "request-events-metadata", event["command"])
+	assert.Equal(t, "abc", event["request_id"])
+	assert.IsType(t, time.Duration(0), event["duration"])
+}
+
+func TestGeneratedRequestIDIsLogged(t *testing.T) {
+	var ids []string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://bah.com/dataset",
+		func(req *http.Request) (*http.Response, error) {
+			ids = append(ids, req.Header.Get(RequestIDHeader))
+			if len(ids) == 1 {
+				return httpmock.NewStringResponse(503, ""), nil
+			}
+			return httpmock.NewStringResponse(200, "{}"), nil
+		})
+
+	logger := &recordingLogger{}
+	policy := NewBackoffPolicy(2)
+	policy.BaseDelay = time.Millisecond
+
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(transport),
+		WithMiddleware(RequestID()),
+		WithCommandConfig(hystrix.CommandConfig{Timeout: 1000}),
+		WithRetryPolicy(policy),
+		WithLogger(logger),
+	)
+
+	client.Get("/dataset", nil)
+	client.Get("/dataset", nil)
+
+	assert.Len(t, ids, 3)
+	assert.Len(t, ids[0], 32)
+	assert.Equal(t, ids[0], ids[1])
+	assert.NotEqual(t, ids[1], ids[2])
+
+	assert.Equal(t, []string{"retry", "request", "request"}, logger.names)
+	assert.Equal(t, ids[0], logger.events[0]["request_id"])
+	assert.Equal(t, ids[1], logger.events[1]["request_id"])
+	assert.Equal(t, ids[2], logger.events[2]["request_id"])
+}
+
+func TestCircuitOpenGauge(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://bah.com/search",
+		httpmock.NewStringResponder(503, ""))
+
+	registry := metrics.NewRegistry()
+	client := NewHttpClient(
+		WithBaseUrl("http://bah.com"),
+		WithTransport(transport),
+		WithOperationConfig(OperationSearch, hystrix.CommandConfig{
+			Timeout:                1000,
+			RequestVolumeThreshold: 1,
+			ErrorPercentThreshold:  1,
+			SleepWindow:            60000,
+		}),
+		WithMetrics(registry),
+	)
+
+	scrape := func() string {
+		var out strings.Builder
+		registry.WriteTo(&out)
+		return out.String()
+	}
+
+	assert.Contains(t, scrape(), `apipoc_client_circuit_open{operation="search"} 0`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(), `apipoc_client_circuit_open{operation="search"} 1`) {
+		if time.Now().After(deadline) {
+			t.Fatal("circuit never opened")
+		}
+		client.Get("/search", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Contains(t, scrape(), `apipoc_client_circuit_open{operation="data"} 0`)
+}
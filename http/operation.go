@@ -0,0 +1,49 @@
+package http
+
+import "strings"
+
+// Operation is a logical group of API endpoints sharing a hystrix circuit.
+type Operation string
+
+const (
+	OperationPing     Operation = "ping"
+	OperationStatus   Operation = "status"
+	OperationMetadata Operation = "metadata"
+	OperationSearch   Operation = "search"
+	OperationData     Operation = "data"
+)
+
+var Operations = []Operation{
+	OperationPing,
+	OperationStatus,
+	OperationMetadata,
+	OperationSearch,
+	OperationData,
+}
+
+// OperationForPath returns the Operation whose circuit guards requests to path.
+func OperationForPath(path string) Operation {
+	switch {
+	case path == "/ops/ping":
+		return OperationPing
+	case path == "/ops/status":
+		return OperationStatus
+	case strings.HasPrefix(path, "/search"):
+		return OperationSearch
+	case isDataPath(path):
+		return OperationData
+	default:
+		return OperationMetadata
+	}
+}
+
+// isDataPath matches /dataset/{id}/timeseries/{id}/data by segment, so that a
+// dataset or timeseries whose id is "data" is still metadata.
+func isDataPath(path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	return len(segments) == 5 &&
+		segments[0] == "dataset" &&
+		segments[2] == "timeseries" &&
+		segments[4] == "data"
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+type Option func(*config)
+
+type config struct {
+	baseUrl          string
+	client           *http.Client
+	transport        http.RoundTripper
+	middlewares      []Middleware
+	commandName      string
+	commandConfig    hystrix.CommandConfig
+	operationConfigs map[Operation]hystrix.CommandConfig
+	logger           logging.Logger
+	userAgent        string
+	headers          http.Header
+	retryPolicy      RetryPolicy
+	metrics          *metrics.Registry
+}
+
+// WithBaseUrl overrides the API_SERVER_ROOT environment variable.
+func WithBaseUrl(url string) Option {
+	return func(c *config) {
+		c.baseUrl = url
+	}
+}
+
+// WithClient replaces the default *http.Client, whose timeout is 10 seconds.
+func WithClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithTransport sets the RoundTripper used by the *http.Client. A client
+// passed to WithClient is copied rather than modified.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithMiddleware layers middlewares over the transport, the first
+// outermost. It may be given more than once.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithCommandName sets the prefix of the hystrix command names, which are
+// suffixed with each Operation. By default every client is given a unique
+// prefix, so clients sharing a name also share their circuits. See
+// client.WithHystrixCommandName for when to share one.
+func WithCommandName(name string) Option {
+	return func(c *config) {
+		c.commandName = name
+	}
+}
+
+// WithCommandConfig sets the hystrix settings for every operation that has
+// not been configured with WithOperationConfig.
+func WithCommandConfig(commandConfig hystrix.CommandConfig) Option {
+	return func(c *config) {
+		c.commandConfig = commandConfig
+	}
+}
+
+func WithOperationConfig(op Operation, commandConfig hystrix.CommandConfig) Option {
+	return func(c *config) {
+		c.operationConfigs[op] = commandConfig
+	}
+}
+
+// WithLogger replaces the default logger, which writes info and higher
+// events to stdout as JSON.
+func WithLogger(logger logging.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key string, value string) Option {
+	return func(c *config) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithRetryPolicy retries failed attempts inside the hystrix command, so the
+// command timeout bounds the total time spent across all attempts.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithMetrics records retries, fallbacks, timeouts and short circuits for
+// each operation in registry, along with whether its circuit is open.
+func WithMetrics(registry *metrics.Registry) Option {
+	return func(c *config) {
+		c.metrics = registry
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt should be retried and how long
+// to wait first. attempt starts at 1 for the first attempt. resp is nil when
+// err is not.
+type RetryPolicy interface {
+	Retry(attempt int, req *http.Request, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// BackoffPolicy retries transport errors and retryable status codes with
+// exponential backoff and full jitter, honouring any Retry-After header. A
+// Retry-After longer than MaxDelay is not retried.
+// Only idempotent methods are retried unless Methods says otherwise.
+type BackoffPolicy struct {
+	MaxAttempts          int
+	BaseDelay            time.Duration
+	MaxDelay             time.Duration
+	RetryableStatusCodes []int
+	Methods              []string
+}
+
+func NewBackoffPolicy(maxAttempts int) *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		Methods: []string{"GET", "HEAD"},
+	}
+}
+
+func (p *BackoffPolicy) Retry(attempt int, req *http.Request, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !contains(p.Methods, req.Method) {
+		return 0, false
+	}
+
+	if err != nil {
+		return p.backoff(attempt), true
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			if delay, ok := retryAfter(resp); ok {
+				// Retrying sooner than the server asked would not honour it.
+				if p.MaxDelay > 0 && delay > p.MaxDelay {
+					return 0, false
+				}
+				return delay, true
+			}
+			return p.backoff(attempt), true
+		}
+	}
+
+	return 0, false
+}
+
+func (p *BackoffPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.cap(p.BaseDelay << uint(attempt-1))
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func (p *BackoffPolicy) cap(delay time.Duration) time.Duration {
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay < 0) {
+		return p.MaxDelay
+	}
+
+	return delay
+}
+
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if len(value) <= 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestBackoffPolicyRetriesTransportErrors(t *testing.T) {
+	policy := NewBackoffPolicy(3)
+	req, _ := http.NewRequest("GET", "http://bah.com/ops/status", nil)
+
+	delay, retry := policy.Retry(1, req, nil, errors.New("connection reset"))
+
+	assert.True(t, retry)
+	assert.True(t, delay >= 0 && delay <= policy.BaseDelay)
+}
+
+func TestBackoffPolicyStopsAtMaxAttempts(t *testing.T) {
+	policy := NewBackoffPolicy(3)
+	req, _ := http.NewRequest("GET", "http://bah.com/ops/status", nil)
+
+	_, retry := policy.Retry(3, req, nil, errors.New("connection reset"))
+
+	assert.False(t, retry)
+}
+
+func TestBackoffPolicyOnlyRetriesIdempotentMethods(t *testing.T) {
+	policy := NewBackoffPolicy(3)
+	req, _ := http.NewRequest("POST", "http://bah.com/ops/status", nil)
+
+	_, retry := policy.Retry(1, req, nil, errors.New("connection reset"))
+
+	assert.False(t, retry)
+}
+
+func TestBackoffPolicyRetryableStatusCodes(t *testing.T) {
+	policy := NewBackoffPolicy(3)
+	req, _ := http.NewRequest("GET", "http://bah.com/ops/status", nil)
+
+	_, retry := policy.Retry(1, req, httpmock.NewStringResponse(502, ""), nil)
+	assert.True(t, retry)
+
+	_, retry = policy.Retry(1, req, httpmock.NewStringResponse(500, ""), nil)
+	assert.False(t, retry)
+
+	_, retry = policy.Retry(1, req, httpmock.NewStringResponse(404, ""), nil)
+	assert.False(t, retry)
+}
+
+func TestBackoffPolicyHonoursRetryAfter(t *testing.T) {
+	policy := NewBackoffPolicy(3)
+	req, _ := http.NewRequest("GET", "http://bah.com/ops/status", nil)
+
+	resp := httpmock.NewStringResponse(503, "")
+	resp.Header.Set("Retry-After", "1")
+
+	delay, retry := policy.Retry(1, req, resp, nil)
+
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, delay)
+
+	resp.Header.Set("Retry-After", "120")
+
+	delay, retry = policy.Retry(1, req, resp, nil)
+
+	assert.False(t, retry)
+	assert.Equal(t, time.Duration(0), delay)
+}
+
+func TestBackoffPolicyDelayIsBounded(t *testing.T) {
+	policy := NewBackoffPolicy(100)
+	req, _ := http.NewRequest("GET", "http://bah.com/ops/status", nil)
+
+	for attempt := 1; attempt < 80; attempt++ {
+		delay, retry := policy.Retry(attempt, req, nil, errors.New("connection reset"))
+
+		assert.True(t, retry)
+		assert.True(t, delay >= 0 && delay <= policy.MaxDelay)
+	}
+}
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+)
+
+// Middleware wraps the RoundTripper beneath the hystrix command, so each
+// retry attempt passes through it. Middlewares given to WithMiddleware are
+// applied with the first outermost.
+//
+// As with any RoundTripper, a middleware must not modify the request it is
+// given; clone it first. It must return either a response or an error, and
+// close the body of any response it discards.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// defaultTransport defers to http.DefaultTransport when a request is made
+// rather than when the client is built.
+var defaultTransport = RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req)
+})
+
+func chain(base http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	if base == nil {
+		base = defaultTransport
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+
+	return base
+}
+
+// InjectHeader sets key on every request to the result of value, e.g. a
+// short-lived token. Nothing is set when value returns an empty string.
+func InjectHeader(key string, value func(req *http.Request) string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			v := value(req)
+			if len(v) == 0 {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set(key, v)
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID sets the X-Request-Id header on requests that do not already
+// have one, so they can be traced through the API server's logs. Every
+// attempt of a retried request carries the same id, which is also logged
+// with its request event.
+func RequestID() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get(RequestIDHeader)) > 0 {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set(RequestIDHeader, requestIDFor(req.Context()))
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+type requestIDKey struct{}
+
+// requestIDSlot holds the id of a request made by an HttpClient, generated
+// when RequestID first needs it so that retries and log events share it.
+type requestIDSlot struct {
+	sync.Mutex
+	id string
+}
+
+func withRequestIDSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, &requestIDSlot{})
+}
+
+func (s *requestIDSlot) get() string {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.id) <= 0 {
+		s.id = newRequestID()
+	}
+	return s.id
+}
+
+func requestIDFor(ctx context.Context) string {
+	if slot, ok := ctx.Value(requestIDKey{}).(*requestIDSlot); ok {
+		return slot.get()
+	}
+	return newRequestID()
+}
+
+// requestIDOf returns the id req was sent with: its own header, or the one
+// RequestID generated for it, if any.
+func requestIDOf(req *http.Request) string {
+	if id := req.Header.Get(RequestIDHeader); len(id) > 0 {
+		return id
+	}
+
+	if slot, ok := req.Context().Value(requestIDKey{}).(*requestIDSlot); ok {
+		slot.Lock()
+		defer slot.Unlock()
+
+		return slot.id
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Dump writes every request and response to w, including their bodies if
+// body is set. Intended for debugging only.
+func Dump(w io.Writer, body bool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if dump, err := httputil.DumpRequestOut(req, body); err == nil {
+				w.Write(dump)
+				io.WriteString(w, "\n")
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				io.WriteString(w, "error: "+err.Error()+"\n\n")
+				return resp, err
+			}
+
+			if dump, err := httputil.DumpResponse(resp, body); err == nil {
+				w.Write(dump)
+				io.WriteString(w, "\n\n")
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// Compression asks for gzip-encoded responses and decompresses them. The
+// standard transport already does this by default; the middleware is for
+// custom transports, or for dumping the compressed exchange beneath it.
+func Compression() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get("Accept-Encoding")) == 0 {
+				req = req.Clone(req.Context())
+				req.Header.Set("Accept-Encoding", "gzip")
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.Header.Get("Content-Encoding") != "gzip" {
+				return resp, err
+			}
+
+			reader, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+
+			resp.Body = &gzipBody{Reader: reader, body: resp.Body}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+
+			return resp, nil
+		})
+	}
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// Fault describes a failure to inject. Probability is the fraction of
+// requests affected, from 0 to 1. Latency delays affected requests; then Err
+// is returned if set, or else a response with StatusCode if that is set.
+// Otherwise the delayed request is sent as normal.
+type Fault struct {
+	Probability float64
+	Latency     time.Duration
+	StatusCode  int
+	Err         error
+}
+
+// FaultInjection injects fault into a random sample of requests, to
+// exercise timeouts, retries and circuit breaking.
+func FaultInjection(fault Fault) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if fault.Probability <= 0 || mathrand.Float64() >= math.Min(fault.Probability, 1) {
+				return next.RoundTrip(req)
+			}
+
+			if fault.Latency > 0 {
+				if err := sleep(req.Context(), fault.Latency); err != nil {
+					return nil, err
+				}
+			}
+
+			if fault.Err != nil {
+				return nil, fault.Err
+			}
+
+			if fault.StatusCode != 0 {
+				return &http.Response{
+					Status:        http.StatusText(fault.StatusCode),
+					StatusCode:    fault.StatusCode,
+					Proto:         "HTTP/1.1",
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					Header:        make(http.Header),
+					Body:          ioutil.NopCloser(bytes.NewReader(nil)),
+					ContentLength: 0,
+					Request:       req,
+				}, nil
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
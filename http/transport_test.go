@@ -0,0 +1,164 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func echoTransport(seen *[]*http.Request) RoundTripperFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		*seen = append(*seen, req)
+		return httpmock.NewStringResponse(200, "ok"), nil
+	}
+}
+
+func roundTrip(t *testing.T, rt http.RoundTripper, req *http.Request) *http.Response {
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestInjectHeader(t *testing.T) {
+	var seen []*http.Request
+	rt := InjectHeader("Authorization", func(req *http.Request) string {
+		return "Bearer " + req.URL.Path
+	})(echoTransport(&seen))
+
+	req, _ := http.NewRequest("GET", "http://foo.com/token", nil)
+	roundTrip(t, rt, req)
+
+	assert.Equal(t, "Bearer /token", seen[0].Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestRequestID(t *testing.T) {
+	var seen []*http.Request
+	rt := RequestID()(echoTransport(&seen))
+
+	req, _ := http.NewRequest("GET", "http://foo.com/", nil)
+	roundTrip(t, rt, req)
+	roundTrip(t, rt, req)
+
+	assert.Len(t, seen[0].Header.Get(RequestIDHeader), 32)
+	assert.NotEqual(t, seen[0].Header.Get(RequestIDHeader), seen[1].Header.Get(RequestIDHeader))
+
+	req.Header.Set(RequestIDHeader, "given")
+	roundTrip(t, rt, req)
+
+	assert.Equal(t, "given", seen[2].Header.Get(RequestIDHeader))
+}
+
+func TestDump(t *testing.T) {
+	var seen []*http.Request
+	var out bytes.Buffer
+	rt := Dump(&out, true)(echoTransport(&seen))
+
+	req, _ := http.NewRequest("GET", "http://foo.com/ops/status", nil)
+	resp := roundTrip(t, rt, req)
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, "ok", string(body))
+	assert.Contains(t, out.String(), "GET /ops/status HTTP/1.1")
+	assert.Contains(t, out.String(), "200")
+	assert.Contains(t, out.String(), "\r\n\r\nok")
+}
+
+func TestCompression(t *testing.T) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	w.Write([]byte(`{"applicationName": "gzip"}`))
+	w.Close()
+
+	var accept string
+	rt := Compression()(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		accept = req.Header.Get("Accept-Encoding")
+
+		resp := httpmock.NewBytesResponse(200, compressed.Bytes())
+		resp.Header.Set("Content-Encoding", "gzip")
+		return resp, nil
+	}))
+
+	req, _ := http.NewRequest("GET", "http://foo.com/ops/status", nil)
+	resp := roundTrip(t, rt, req)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, "gzip", accept)
+	assert.Equal(t, `{"applicationName": "gzip"}`, string(body))
+	assert.True(t, resp.Uncompressed)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+}
+
+func TestFaultInjection(t *testing.T) {
+	var seen []*http.Request
+	failure := errors.New("injected")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/", nil)
+
+	resp := roundTrip(t, FaultInjection(Fault{Probability: 1, StatusCode: 503})(echoTransport(&seen)), req)
+	assert.Equal(t, 503, resp.StatusCode)
+
+	_, err := FaultInjection(Fault{Probability: 1, Err: failure})(echoTransport(&seen)).RoundTrip(req)
+	assert.Equal(t, failure, err)
+
+	resp = roundTrip(t, FaultInjection(Fault{Probability: 0, StatusCode: 503})(echoTransport(&seen)), req)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, seen, 1)
+}
+
+func TestWithMiddleware(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/ops/status",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, req.Header.Get("X-Order")), nil
+		})
+
+	appender := func(name string) Middleware {
+		return InjectHeader("X-Order", func(req *http.Request) string {
+			return strings.TrimPrefix(req.Header.Get("X-Order")+","+name, ",")
+		})
+	}
+
+	client := NewHttpClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithMiddleware(appender("outer"), appender("inner")),
+		WithLogger(logging.Nop()),
+	)
+
+	response := client.Get("/ops/status", nil)
+	body, _ := ioutil.ReadAll(response.Success.Body)
+
+	assert.Nil(t, response.Failure)
+	assert.Equal(t, "outer,inner", string(body))
+}
+
+func TestWithMiddlewareUsesDefaultTransportWhenCalled(t *testing.T) {
+	client := NewHttpClient(
+		WithBaseUrl("http://foo.com"),
+		WithMiddleware(FaultInjection(Fault{Probability: 1, Latency: time.Millisecond})),
+		WithLogger(logging.Nop()),
+	)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("HEAD", "http://foo.com/ops/ping", httpmock.NewStringResponder(200, ""))
+
+	response := client.Head("/ops/ping")
+
+	assert.Nil(t, response.Failure)
+	assert.Equal(t, 200, response.Success.StatusCode)
+}
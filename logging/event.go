@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/go-ns/log"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Data holds the fields of an event. Durations are logged in nanoseconds and
+// errors as their message.
+type Data map[string]interface{}
+
+// Logger records structured, levelled events. Implementations must be safe
+// for concurrent use and must not retain data after Event returns.
+//
+// The client logs these events:
+//   - "request" (debug, or error on failure) for each request, with method,
+//     path, params, status, duration, attempts and circuit;
+//   - "retry" (warn) before a request is retried, with method, path, attempt,
+//     delay and status or error;
+//   - "api_error" (error) when a client method fails, with path, status and
+//     error.
+type Logger interface {
+	Event(level Level, name string, data Data)
+}
+
+type nop struct{}
+
+func (nop) Event(Level, string, Data) {}
+
+// Nop returns a Logger that discards every event.
+func Nop() Logger {
+	return nop{}
+}
+
+type jsonLogger struct {
+	sync.Mutex
+	w   io.Writer
+	min Level
+	now func() time.Time
+}
+
+// NewJSON writes events at or above min to w, one JSON object per line with
+// the fields created, level, event and data.
+func NewJSON(w io.Writer, min Level) Logger {
+	return &jsonLogger{w: w, min: min, now: time.Now}
+}
+
+func (l *jsonLogger) Event(level Level, name string, data Data) {
+	if level < l.min {
+		return
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"created": l.now(),
+		"level":   level.String(),
+		"event":   name,
+		"data":    encodable(data),
+	})
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			"created": l.now(),
+			"level":   LevelError.String(),
+			"event":   "log_error",
+			"data":    map[string]interface{}{"event": name, "error": err.Error()},
+		})
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	fmt.Fprintf(l.w, "%s\n", b)
+}
+
+// encodable replaces errors, which marshal as empty objects, with their
+// messages.
+func encodable(data Data) Data {
+	out := make(Data, len(data))
+	for key, value := range data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		out[key] = value
+	}
+
+	return out
+}
+
+type goNSLogger struct {
+	min Level
+}
+
+// NewGoNS sends events at or above min to github.com/ONSdigital/go-ns/log,
+// so they share the format and HUMAN_LOG setting of other ONS services. The
+// level becomes the go-ns event type and the client's event name is added
+// to the data as "event". A "request_id" field is used as the go-ns context.
+func NewGoNS(min Level) Logger {
+	return goNSLogger{min: min}
+}
+
+func (l goNSLogger) Event(level Level, name string, data Data) {
+	if level < l.min {
+		return
+	}
+
+	d := log.Data(encodable(data))
+	d["event"] = name
+
+	requestID, _ := data["request_id"].(string)
+
+	log.Event(level.String(), requestID, d)
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlog sends events to logger, using the event name as the message and
+// the data, sorted by key, as attributes.
+func NewSlog(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+var slogLevels = map[Level]slog.Level{
+	LevelDebug: slog.LevelDebug,
+	LevelInfo:  slog.LevelInfo,
+	LevelWarn:  slog.LevelWarn,
+	LevelError: slog.LevelError,
+}
+
+func (l slogLogger) Event(level Level, name string, data Data) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, data[key]))
+	}
+
+	l.logger.LogAttrs(context.Background(), slogLevels[level], name, attrs...)
+}
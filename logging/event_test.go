@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := NewJSON(&buf, LevelInfo).(*jsonLogger)
+	logger.now = func() time.Time { return time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC) }
+
+	logger.Event(LevelDebug, "request", Data{"path": "/ops/ping"})
+	logger.Event(LevelError, "request", Data{"path": "/ops/ping", "error": errors.New("boom"), "duration": time.Millisecond})
+
+	assert.Equal(t, `{"created":"2017-03-01T00:00:00Z","data":{"duration":1000000,"error":"boom","path":"/ops/ping"},"event":"request","level":"error"}`+"\n", buf.String())
+}
+
+func TestJSONUnencodableData(t *testing.T) {
+	var buf bytes.Buffer
+
+	NewJSON(&buf, LevelDebug).Event(LevelInfo, "call", Data{"bad": func() {}})
+
+	var event map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &event))
+	assert.Equal(t, "log_error", event["event"])
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlog(slog.New(handler))
+
+	logger.Event(LevelInfo, "request", Data{"path": "/dataset"})
+	logger.Event(LevelWarn, "retry", Data{"path": "/dataset", "attempt": 1})
+
+	assert.Equal(t, "level=WARN msg=retry attempt=1 path=/dataset\n", buf.String())
+}
+
+func TestGoNS(t *testing.T) {
+	var name, context string
+	var data log.Data
+
+	original := log.Event
+	defer func() { log.Event = original }()
+
+	log.Event = func(n string, c string, d log.Data) {
+		name, context, data = n, c, d
+	}
+
+	NewGoNS(LevelInfo).Event(LevelDebug, "request", Data{"path": "/dataset"})
+	assert.Equal(t, "", name)
+
+	NewGoNS(LevelInfo).Event(LevelError, "api_error", Data{"request_id": "abc", "error": errors.New("boom")})
+	assert.Equal(t, "error", name)
+	assert.Equal(t, "abc", context)
+	assert.Equal(t, log.Data{"event": "api_error", "request_id": "abc", "error": "boom"}, data)
+}
+
+func TestNop(t *testing.T) {
+	Nop().Event(LevelError, "request", Data{"path": "/ops/ping"})
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 )
 
+// Deprecated: the client logs through a Logger. These loggers are kept for
+// callers that still use Init.
 var (
 	Trace   *log.Logger
 	Info    *log.Logger
@@ -12,6 +14,7 @@ var (
 	Error   *log.Logger
 )
 
+// Deprecated: see Logger.
 func Init(
 	traceHandle io.Writer,
 	infoHandle io.Writer,
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+)
+
+// RegisterHystrix adds r to the hystrix metric collector registry, recording
+// apipoc_hystrix_* counters and a run duration histogram labelled by command
+// name. The registry is global to the process, so every hystrix command
+// created afterwards is recorded, including those of other libraries;
+// commands created before the call are not. Call it at most once.
+func (r *Registry) RegisterHystrix() {
+	metricCollector.Registry.Register(func(name string) metricCollector.MetricCollector {
+		return &hystrixCollector{registry: r, command: name}
+	})
+}
+
+// RegisterCircuit reports whether the circuit of the hystrix command serving
+// operation is open, as apipoc_client_circuit_open{operation=...}, read each
+// time r is written. http.WithMetrics registers every operation of a client.
+func (r *Registry) RegisterCircuit(operation string, command string) {
+	r.GaugeFunc("apipoc_client_circuit_open", "Whether the circuit for the operation is open.", []string{"operation"}, func() float64 {
+		circuit, _, err := hystrix.GetCircuit(command)
+		if err != nil || !circuit.IsOpen() {
+			return 0
+		}
+		return 1
+	}, operation)
+}
+
+type hystrixCollector struct {
+	registry *Registry
+	command  string
+}
+
+func (c *hystrixCollector) inc(name string, help string) {
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+
+	c.registry.inc(name, help, []string{"command"}, c.command)
+}
+
+func (c *hystrixCollector) IncrementAttempts() {
+	c.inc("apipoc_hystrix_attempts_total", "Hystrix command executions.")
+}
+
+func (c *hystrixCollector) IncrementErrors() {
+	c.inc("apipoc_hystrix_errors_total", "Hystrix command executions that did not succeed.")
+}
+
+func (c *hystrixCollector) IncrementSuccesses() {
+	c.inc("apipoc_hystrix_successes_total", "Hystrix command executions that succeeded.")
+}
+
+func (c *hystrixCollector) IncrementFailures() {
+	c.inc("apipoc_hystrix_failures_total", "Hystrix command executions that returned an error.")
+}
+
+func (c *hystrixCollector) IncrementRejects() {
+	c.inc("apipoc_hystrix_rejects_total", "Hystrix command executions rejected for lack of capacity.")
+}
+
+func (c *hystrixCollector) IncrementShortCircuits() {
+	c.inc("apipoc_hystrix_short_circuits_total", "Hystrix command executions rejected by an open circuit.")
+}
+
+func (c *hystrixCollector) IncrementTimeouts() {
+	c.inc("apipoc_hystrix_timeouts_total", "Hystrix command executions that timed out.")
+}
+
+func (c *hystrixCollector) IncrementFallbackSuccesses() {
+	c.inc("apipoc_hystrix_fallback_successes_total", "Hystrix fallbacks that succeeded.")
+}
+
+func (c *hystrixCollector) IncrementFallbackFailures() {
+	c.inc("apipoc_hystrix_fallback_failures_total", "Hystrix fallbacks that failed.")
+}
+
+func (c *hystrixCollector) UpdateTotalDuration(timeSinceStart time.Duration) {}
+
+func (c *hystrixCollector) UpdateRunDuration(runDuration time.Duration) {
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+
+	c.registry.observe("apipoc_hystrix_run_duration_seconds", "Hystrix command run latency.", []string{"command"}, runDuration, c.command)
+}
+
+// Reset is called when hystrix flushes its metrics. Prometheus counters must
+// not go backwards, so it does nothing.
+func (c *hystrixCollector) Reset() {}
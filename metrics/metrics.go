@@ -0,0 +1,351 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the latency histogram bounds, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Failure classes recorded by ObserveRequest.
+const (
+	ClassTransport   = "transport"
+	ClassTimeout     = "timeout"
+	ClassCircuitOpen = "circuit_open"
+	ClassStatus      = "status"
+	ClassDecode      = "decode"
+	ClassCanceled    = "canceled"
+	ClassStale       = "stale"
+	ClassInvalid     = "invalid"
+	ClassOther       = "other"
+)
+
+// Registry holds per-operation counters and latency histograms and serves
+// them in the Prometheus text exposition format. It is safe for concurrent
+// use. Metrics only appear once they have been recorded.
+type Registry struct {
+	mu         sync.Mutex
+	buckets    []float64
+	counters   map[string]*counter
+	histograms map[string]*histogram
+	gauges     map[string]*gauge
+}
+
+type counter struct {
+	help   string
+	labels []string
+	values map[string]uint64
+}
+
+// gauge values are read from their functions each time the registry is
+// written.
+type gauge struct {
+	help   string
+	labels []string
+	values map[string]func() float64
+}
+
+type histogram struct {
+	help   string
+	labels []string
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewRegistry creates a Registry with the given histogram buckets, in
+// seconds, or DefaultBuckets if none are given.
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+
+	return &Registry{
+		buckets:    b,
+		counters:   make(map[string]*counter),
+		histograms: make(map[string]*histogram),
+		gauges:     make(map[string]*gauge),
+	}
+}
+
+// ObserveRequest records one client call for operation. An empty class
+// records a success; otherwise class is one of the Class constants.
+func (r *Registry) ObserveRequest(operation string, class string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inc("apipoc_client_requests_total", "Client calls.", []string{"operation"}, operation)
+
+	if len(class) == 0 {
+		r.inc("apipoc_client_successes_total", "Client calls that succeeded.", []string{"operation"}, operation)
+	} else {
+		r.inc("apipoc_client_failures_total", "Client calls that failed, by class.", []string{"operation", "class"}, operation, class)
+	}
+
+	r.observe("apipoc_client_request_duration_seconds", "Client call latency.", []string{"operation"}, duration, operation)
+}
+
+// IncTimeouts records a request abandoned by its hystrix command timeout.
+func (r *Registry) IncTimeouts(operation string) {
+	r.incOperation("apipoc_client_timeouts_total", "Requests abandoned by the hystrix timeout.", operation)
+}
+
+// IncShortCircuits records a request rejected because its circuit was open.
+func (r *Registry) IncShortCircuits(operation string) {
+	r.incOperation("apipoc_client_short_circuits_total", "Requests rejected by an open circuit.", operation)
+}
+
+// IncFallbacks records a request answered by its hystrix fallback.
+func (r *Registry) IncFallbacks(operation string) {
+	r.incOperation("apipoc_client_fallbacks_total", "Requests answered by the hystrix fallback.", operation)
+}
+
+func (r *Registry) IncRetries(operation string) {
+	r.incOperation("apipoc_client_retries_total", "Request attempts that were retried.", operation)
+}
+
+func (r *Registry) IncCacheHits(operation string) {
+	r.incOperation("apipoc_client_cache_hits_total", "Calls served from the cache.", operation)
+}
+
+func (r *Registry) IncCacheMisses(operation string) {
+	r.incOperation("apipoc_client_cache_misses_total", "Calls not found fresh in the cache.", operation)
+}
+
+func (r *Registry) incOperation(name string, help string, operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inc(name, help, []string{"operation"}, operation)
+}
+
+// Counter returns the current value of a counter, e.g.
+// Counter("apipoc_client_failures_total", "data", "timeout").
+func (r *Registry) Counter(name string, labelValues ...string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.counters[name]; ok {
+		return c.values[key(labelValues)]
+	}
+
+	return 0
+}
+
+// GaugeFunc registers a gauge whose value is read from fn each time the
+// registry is written, replacing any function registered for the same label
+// values.
+func (r *Registry) GaugeFunc(name string, help string, labels []string, fn func() float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, ok := r.gauges[name]
+	if !ok {
+		g = &gauge{help: help, labels: labels, values: make(map[string]func() float64)}
+		r.gauges[name] = g
+	}
+
+	g.values[key(labelValues)] = fn
+}
+
+func (r *Registry) inc(name string, help string, labels []string, labelValues ...string) {
+	c, ok := r.counters[name]
+	if !ok {
+		c = &counter{help: help, labels: labels, values: make(map[string]uint64)}
+		r.counters[name] = c
+	}
+
+	c.values[key(labelValues)]++
+}
+
+func (r *Registry) observe(name string, help string, labels []string, duration time.Duration, labelValues ...string) {
+	h, ok := r.histograms[name]
+	if !ok {
+		h = &histogram{help: help, labels: labels, values: make(map[string]*histogramValue)}
+		r.histograms[name] = h
+	}
+
+	k := key(labelValues)
+	v, ok := h.values[k]
+	if !ok {
+		v = &histogramValue{counts: make([]uint64, len(r.buckets))}
+		h.values[k] = v
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += seconds
+}
+
+func key(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// ServeHTTP writes every metric in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the Prometheus text format, ordered by name
+// and then by label values.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	// Gauge functions may take locks of their own, e.g. hystrix's, whose
+	// holders record metrics; read them before locking r.
+	gauges := r.readGauges()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	names := make([]string, 0, len(r.counters)+len(r.histograms)+len(gauges))
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	for name := range r.histograms {
+		names = append(names, name)
+	}
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if g, ok := gauges[name]; ok {
+			fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+			for _, k := range sortedKeys(g.values) {
+				fmt.Fprintf(cw, "%s%s %s\n", name, labelString(g.labels, k, ""), formatFloat(g.values[k]))
+			}
+			continue
+		}
+
+		if c, ok := r.counters[name]; ok {
+			fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s counter\n", name, c.help, name)
+			for _, k := range sortedKeys(c.values) {
+				fmt.Fprintf(cw, "%s%s %d\n", name, labelString(c.labels, k, ""), c.values[k])
+			}
+			continue
+		}
+
+		h := r.histograms[name]
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s histogram\n", name, h.help, name)
+		for _, k := range sortedKeys(h.values) {
+			v := h.values[k]
+			for i, bound := range r.buckets {
+				fmt.Fprintf(cw, "%s_bucket%s %d\n", name, labelString(h.labels, k, formatFloat(bound)), v.counts[i])
+			}
+			fmt.Fprintf(cw, "%s_bucket%s %d\n", name, labelString(h.labels, k, "+Inf"), v.count)
+			fmt.Fprintf(cw, "%s_sum%s %s\n", name, labelString(h.labels, k, ""), formatFloat(v.sum))
+			fmt.Fprintf(cw, "%s_count%s %d\n", name, labelString(h.labels, k, ""), v.count)
+		}
+	}
+
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+
+	return cw.n, cw.err
+}
+
+type gaugeValues struct {
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+func (r *Registry) readGauges() map[string]gaugeValues {
+	r.mu.Lock()
+	funcs := make(map[string]*gauge, len(r.gauges))
+	for name, g := range r.gauges {
+		copied := &gauge{help: g.help, labels: g.labels, values: make(map[string]func() float64, len(g.values))}
+		for k, fn := range g.values {
+			copied.values[k] = fn
+		}
+		funcs[name] = copied
+	}
+	r.mu.Unlock()
+
+	gauges := make(map[string]gaugeValues, len(funcs))
+	for name, g := range funcs {
+		values := make(map[string]float64, len(g.values))
+		for k, fn := range g.values {
+			values[k] = fn()
+		}
+		gauges[name] = gaugeValues{help: g.help, labels: g.labels, values: values}
+	}
+
+	return gauges
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func labelString(names []string, k string, le string) string {
+	values := strings.Split(k, "\xff")
+
+	var pairs []string
+	for i, name := range names {
+		if i < len(values) {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+		}
+	}
+	if len(le) > 0 {
+		pairs = append(pairs, fmt.Sprintf("le=%q", le))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+
+	return n, err
+}
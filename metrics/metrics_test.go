@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry(0.1, 1)
+
+	r.ObserveRequest("data", "", 50*time.Millisecond)
+	r.ObserveRequest("data", ClassTimeout, 2*time.Second)
+	r.ObserveRequest("search", ClassStatus, 500*time.Millisecond)
+	r.IncRetries("data")
+	r.IncCacheHits("metadata")
+
+	var out strings.Builder
+	_, err := r.WriteTo(&out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `# HELP apipoc_client_cache_hits_total Calls served from the cache.
+# TYPE apipoc_client_cache_hits_total counter
+apipoc_client_cache_hits_total{operation="metadata"} 1
+# HELP apipoc_client_failures_total Client calls that failed, by class.
+# TYPE apipoc_client_failures_total counter
+apipoc_client_failures_total{operation="data",class="timeout"} 1
+apipoc_client_failures_total{operation="search",class="status"} 1
+# HELP apipoc_client_request_duration_seconds Client call latency.
+# TYPE apipoc_client_request_duration_seconds histogram
+apipoc_client_request_duration_seconds_bucket{operation="data",le="0.1"} 1
+apipoc_client_request_duration_seconds_bucket{operation="data",le="1"} 1
+apipoc_client_request_duration_seconds_bucket{operation="data",le="+Inf"} 2
+apipoc_client_request_duration_seconds_sum{operation="data"} 2.05
+apipoc_client_request_duration_seconds_count{operation="data"} 2
+apipoc_client_request_duration_seconds_bucket{operation="search",le="0.1"} 0
+apipoc_client_request_duration_seconds_bucket{operation="search",le="1"} 1
+apipoc_client_request_duration_seconds_bucket{operation="search",le="+Inf"} 1
+apipoc_client_request_duration_seconds_sum{operation="search"} 0.5
+apipoc_client_request_duration_seconds_count{operation="search"} 1
+# HELP apipoc_client_requests_total Client calls.
+# TYPE apipoc_client_requests_total counter
+apipoc_client_requests_total{operation="data"} 2
+apipoc_client_requests_total{operation="search"} 1
+# HELP apipoc_client_retries_total Request attempts that were retried.
+# TYPE apipoc_client_retries_total counter
+apipoc_client_retries_total{operation="data"} 1
+# HELP apipoc_client_successes_total Client calls that succeeded.
+# TYPE apipoc_client_successes_total counter
+apipoc_client_successes_total{operation="data"} 1
+`, out.String())
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+
+	r.ObserveRequest("data", ClassTimeout, time.Millisecond)
+	r.IncShortCircuits("data")
+	r.IncShortCircuits("data")
+
+	assert.Equal(t, uint64(1), r.Counter("apipoc_client_failures_total", "data", "timeout"))
+	assert.Equal(t, uint64(2), r.Counter("apipoc_client_short_circuits_total", "data"))
+	assert.Equal(t, uint64(0), r.Counter("apipoc_client_fallbacks_total", "data"))
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.IncFallbacks("ping")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `apipoc_client_fallbacks_total{operation="ping"} 1`)
+}
+
+func TestHystrixCollector(t *testing.T) {
+	r := NewRegistry()
+	c := &hystrixCollector{registry: r, command: "dp-apipoc-client-1-data"}
+
+	c.IncrementAttempts()
+	c.IncrementErrors()
+	c.IncrementTimeouts()
+	c.IncrementFallbackSuccesses()
+	c.UpdateRunDuration(time.Millisecond)
+	c.Reset()
+
+	assert.Equal(t, uint64(1), r.Counter("apipoc_hystrix_attempts_total", "dp-apipoc-client-1-data"))
+	assert.Equal(t, uint64(1), r.Counter("apipoc_hystrix_timeouts_total", "dp-apipoc-client-1-data"))
+	assert.Equal(t, uint64(1), r.Counter("apipoc_hystrix_fallback_successes_total", "dp-apipoc-client-1-data"))
+
+	var out strings.Builder
+	r.WriteTo(&out)
+
+	assert.Contains(t, out.String(), `apipoc_hystrix_run_duration_seconds_count{command="dp-apipoc-client-1-data"} 1`)
+}
+
+func TestGaugeFunc(t *testing.T) {
+	r := NewRegistry()
+
+	value := 1.0
+	r.GaugeFunc("apipoc_test_gauge", "A gauge.", []string{"operation"}, func() float64 { return value }, "data")
+
+	var out strings.Builder
+	r.WriteTo(&out)
+	assert.Contains(t, out.String(), "# TYPE apipoc_test_gauge gauge\napipoc_test_gauge{operation=\"data\"} 1\n")
+
+	value = 0.5
+	out.Reset()
+	r.WriteTo(&out)
+	assert.Contains(t, out.String(), `apipoc_test_gauge{operation="data"} 0.5`)
+}
+
+func TestRegisterCircuit(t *testing.T) {
+	hystrix.Flush()
+
+	hystrix.ConfigureCommand("metrics-test-open", hystrix.CommandConfig{
+		RequestVolumeThreshold: 1,
+		ErrorPercentThreshold:  1,
+		SleepWindow:            60000,
+	})
+	hystrix.ConfigureCommand("metrics-test-closed", hystrix.CommandConfig{})
+
+	r := NewRegistry()
+	r.RegisterCircuit("data", "metrics-test-open")
+	r.RegisterCircuit("search", "metrics-test-closed")
+
+	scrape := func() string {
+		var out strings.Builder
+		r.WriteTo(&out)
+		return out.String()
+	}
+
+	assert.Contains(t, scrape(), `apipoc_client_circuit_open{operation="data"} 0`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(), `apipoc_client_circuit_open{operation="data"} 1`) {
+		if time.Now().After(deadline) {
+			t.Fatal("circuit never opened")
+		}
+		hystrix.Do("metrics-test-open", func() error { return errors.New("unavailable") }, nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Contains(t, scrape(), `apipoc_client_circuit_open{operation="search"} 0`)
+}
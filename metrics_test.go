@@ -0,0 +1,93 @@
+package client
+
+import (
+	nethttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/cache"
+	"github.com/ONSdigital/dp-apipoc-client/http"
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestWithMetrics(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/dataset?limit=10&start=0",
+		httpmock.NewStringResponder(200, `{"totalItems": 1}`))
+	transport.RegisterResponder("GET", "http://foo.com/dataset/ukea/timeseries/none/data",
+		httpmock.NewStringResponder(404, "not found"))
+
+	calls := 0
+	transport.RegisterResponder("GET", "http://foo.com/search?limit=10&q=gdp&start=0",
+		func(req *nethttp.Request) (*nethttp.Response, error) {
+			calls++
+			if calls == 1 {
+				return httpmock.NewStringResponse(503, ""), nil
+			}
+			return httpmock.NewStringResponse(200, `{}`), nil
+		})
+
+	policy := http.NewBackoffPolicy(2)
+	policy.BaseDelay = time.Millisecond
+
+	registry := metrics.NewRegistry()
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 1000}),
+		WithLogger(logging.Nop()),
+		WithRetryPolicy(policy),
+		WithCache(cache.New(cache.Config{})),
+		WithMetrics(registry),
+	)
+
+	client.GetDatasets(0, 10)
+	client.GetDatasets(0, 10)
+	client.GetData("ukea", "none")
+	client.Search("gdp", 0, 10)
+
+	assert.Equal(t, uint64(2), registry.Counter("apipoc_client_requests_total", "metadata"))
+	assert.Equal(t, uint64(2), registry.Counter("apipoc_client_successes_total", "metadata"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_cache_hits_total", "metadata"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_cache_misses_total", "metadata"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_failures_total", "data", "status"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_retries_total", "search"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_successes_total", "search"))
+}
+
+func TestWithMetricsTimeout(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("HEAD", "http://foo.com/ops/ping", httpmock.NewStringResponder(200, ""))
+
+	registry := metrics.NewRegistry()
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(transport),
+		WithTransportMiddleware(http.FaultInjection(http.Fault{Probability: 1, Latency: 200 * time.Millisecond})),
+		WithHystrixConfig(hystrix.CommandConfig{Timeout: 20}),
+		WithLogger(logging.Nop()),
+		WithMetrics(registry),
+	)
+
+	_, err := client.Ping()
+
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_failures_total", "ping", "timeout"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_timeouts_total", "ping"))
+	assert.Equal(t, uint64(1), registry.Counter("apipoc_client_fallbacks_total", "ping"))
+}
+
+func TestFailureClass(t *testing.T) {
+	assert.Equal(t, "", failureClass(nil))
+	assert.Equal(t, metrics.ClassCircuitOpen, failureClass(&CircuitOpenError{Path: "/", Err: hystrix.ErrCircuitOpen}))
+	assert.Equal(t, metrics.ClassStale, failureClass(&StaleError{Path: "/", Err: &CircuitOpenError{Path: "/", Err: hystrix.ErrCircuitOpen}}))
+	assert.Equal(t, metrics.ClassTimeout, failureClass(&TransportError{Path: "/", Err: hystrix.ErrTimeout}))
+	assert.Equal(t, metrics.ClassTransport, failureClass(&TransportError{Path: "/", Err: assert.AnError}))
+	assert.Equal(t, metrics.ClassDecode, failureClass(&DecodeError{Path: "/", Err: assert.AnError}))
+	assert.Equal(t, metrics.ClassInvalid, failureClass(&PeriodError{Option: "from", Period: "x", Err: assert.AnError}))
+	assert.Equal(t, metrics.ClassOther, failureClass(assert.AnError))
+}
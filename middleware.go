@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// Middleware wraps an ApiClient to add behaviour such as auditing or rate
+// limiting. Middlewares given to WithMiddleware are applied by NewApiClient
+// with the first outermost, so it sees each call first and its result last.
+//
+// A middleware must:
+//   - pass every call on to next unless it deliberately answers it itself,
+//     returning next's status code, value and error unchanged otherwise;
+//   - route each plain method through its Context variant with
+//     context.Background(), since next's plain methods do not call back into
+//     the wrapper;
+//   - be safe for concurrent use.
+//
+// Middlewares that treat every method alike are most easily written with
+// Intercept. Those that need particular methods can embed next in a struct
+// and override both forms of the methods concerned.
+type Middleware func(next ApiClient) ApiClient
+
+// Call describes one ApiClient call. Method is the name of the method
+// without its Context suffix, e.g. "GetData", and Args its arguments after
+// the context. The DataOptions of GetData are given as the query parameters
+// they set, e.g. map[string]string{"frequency": "quarterly"}, which is empty
+// for a full fetch.
+type Call struct {
+	Method    string
+	Operation Operation
+	Args      []interface{}
+}
+
+// Invoker performs a call, returning its status code and error.
+type Invoker func(ctx context.Context) (int, error)
+
+// Interceptor is called in place of every ApiClient method. It must call
+// invoke at most once and return its result unless answering itself.
+type Interceptor func(ctx context.Context, call Call, invoke Invoker) (int, error)
+
+// Intercept builds a Middleware that routes every method through interceptor.
+func Intercept(interceptor Interceptor) Middleware {
+	return func(next ApiClient) ApiClient {
+		return &intercepted{next: next, interceptor: interceptor}
+	}
+}
+
+// Logging logs a "call" event for every call with its method, operation,
+// args, status and duration, at info level or error level on failure.
+func Logging(logger logging.Logger) Middleware {
+	return Intercept(func(ctx context.Context, call Call, invoke Invoker) (int, error) {
+		start := time.Now()
+		statusCode, err := invoke(ctx)
+
+		data := logging.Data{
+			"method":    call.Method,
+			"operation": string(call.Operation),
+			"args":      call.Args,
+			"status":    statusCode,
+			"duration":  time.Since(start),
+		}
+
+		if err != nil {
+			data["error"] = err
+			logger.Event(logging.LevelError, "call", data)
+		} else {
+			logger.Event(logging.LevelInfo, "call", data)
+		}
+
+		return statusCode, err
+	})
+}
+
+// Timing reports the duration of every call to observe.
+func Timing(observe func(call Call, statusCode int, err error, elapsed time.Duration)) Middleware {
+	return Intercept(func(ctx context.Context, call Call, invoke Invoker) (int, error) {
+		start := time.Now()
+		statusCode, err := invoke(ctx)
+		observe(call, statusCode, err, time.Since(start))
+
+		return statusCode, err
+	})
+}
+
+// measure records every call in registry. It is applied by NewApiClient
+// beneath any other middleware.
+func measure(registry *metrics.Registry) Middleware {
+	return Intercept(func(ctx context.Context, call Call, invoke Invoker) (int, error) {
+		start := time.Now()
+		statusCode, err := invoke(ctx)
+		registry.ObserveRequest(string(call.Operation), failureClass(err), time.Since(start))
+
+		return statusCode, err
+	})
+}
+
+func failureClass(err error) string {
+	var circuitErr *CircuitOpenError
+	var statusErr *StatusError
+	var decodeErr *DecodeError
+	var transportErr *TransportError
+	var periodErr *PeriodError
+
+	switch {
+	case err == nil:
+		return ""
+	case IsStale(err):
+		return metrics.ClassStale
+	case errors.As(err, &circuitErr):
+		return metrics.ClassCircuitOpen
+	case IsTimeout(err):
+		return metrics.ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return metrics.ClassCanceled
+	case errors.As(err, &statusErr):
+		return metrics.ClassStatus
+	case errors.As(err, &decodeErr):
+		return metrics.ClassDecode
+	case errors.As(err, &transportErr):
+		return metrics.ClassTransport
+	case errors.As(err, &periodErr):
+		return metrics.ClassInvalid
+	default:
+		return metrics.ClassOther
+	}
+}
+
+type intercepted struct {
+	next        ApiClient
+	interceptor Interceptor
+}
+
+func (c *intercepted) call(ctx context.Context, method string, op Operation, args []interface{}, invoke Invoker) (int, error) {
+	return c.interceptor(ctx, Call{Method: method, Operation: op, Args: args}, invoke)
+}
+
+func (c *intercepted) metadata(ctx context.Context, method string, op Operation, args []interface{},
+	fetch func(ctx context.Context) (int, model.Metadata, error)) (int, model.Metadata, error) {
+
+	var metadata model.Metadata
+	statusCode, err := c.call(ctx, method, op, args, func(ctx context.Context) (int, error) {
+		statusCode, m, err := fetch(ctx)
+		metadata = m
+		return statusCode, err
+	})
+
+	return statusCode, metadata, err
+}
+
+func (c *intercepted) Ping() (int, error) {
+	return c.PingContext(context.Background())
+}
+
+func (c *intercepted) PingContext(ctx context.Context) (int, error) {
+	return c.call(ctx, "Ping", OperationPing, nil, c.next.PingContext)
+}
+
+func (c *intercepted) Status() (int, model.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+func (c *intercepted) StatusContext(ctx context.Context) (int, model.Status, error) {
+	var status model.Status
+	statusCode, err := c.call(ctx, "Status", OperationStatus, nil, func(ctx context.Context) (int, error) {
+		statusCode, s, err := c.next.StatusContext(ctx)
+		status = s
+		return statusCode, err
+	})
+
+	return statusCode, status, err
+}
+
+func (c *intercepted) GetDatasets(start int, limit int) (int, model.Metadata, error) {
+	return c.GetDatasetsContext(context.Background(), start, limit)
+}
+
+func (c *intercepted) GetDatasetsContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetDatasets", OperationMetadata, []interface{}{start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetDatasetsContext(ctx, start, limit)
+	})
+}
+
+func (c *intercepted) GetDatasetsForId(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return c.GetDatasetsForIdContext(context.Background(), datasetId, start, limit)
+}
+
+func (c *intercepted) GetDatasetsForIdContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetDatasetsForId", OperationMetadata, []interface{}{datasetId, start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetDatasetsForIdContext(ctx, datasetId, start, limit)
+	})
+}
+
+func (c *intercepted) GetDatasetsForTimeseries(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return c.GetDatasetsForTimeseriesContext(context.Background(), timeseriesId, start, limit)
+}
+
+func (c *intercepted) GetDatasetsForTimeseriesContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetDatasetsForTimeseries", OperationMetadata, []interface{}{timeseriesId, start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetDatasetsForTimeseriesContext(ctx, timeseriesId, start, limit)
+	})
+}
+
+func (c *intercepted) GetTimeseries(start int, limit int) (int, model.Metadata, error) {
+	return c.GetTimeseriesContext(context.Background(), start, limit)
+}
+
+func (c *intercepted) GetTimeseriesContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetTimeseries", OperationMetadata, []interface{}{start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetTimeseriesContext(ctx, start, limit)
+	})
+}
+
+func (c *intercepted) GetTimeseriesForId(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return c.GetTimeseriesForIdContext(context.Background(), timeseriesId, start, limit)
+}
+
+func (c *intercepted) GetTimeseriesForIdContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetTimeseriesForId", OperationMetadata, []interface{}{timeseriesId, start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetTimeseriesForIdContext(ctx, timeseriesId, start, limit)
+	})
+}
+
+func (c *intercepted) GetTimeseriesForDataset(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return c.GetTimeseriesForDatasetContext(context.Background(), datasetId, start, limit)
+}
+
+func (c *intercepted) GetTimeseriesForDatasetContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "GetTimeseriesForDataset", OperationMetadata, []interface{}{datasetId, start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.GetTimeseriesForDatasetContext(ctx, datasetId, start, limit)
+	})
+}
+
+func (c *intercepted) GetDataset(datasetId string, timeseriesId string) (int, model.Record, error) {
+	return c.GetDatasetContext(context.Background(), datasetId, timeseriesId)
+}
+
+func (c *intercepted) GetDatasetContext(ctx context.Context, datasetId string, timeseriesId string) (int, model.Record, error) {
+	var record model.Record
+	statusCode, err := c.call(ctx, "GetDataset", OperationMetadata, []interface{}{datasetId, timeseriesId}, func(ctx context.Context) (int, error) {
+		statusCode, r, err := c.next.GetDatasetContext(ctx, datasetId, timeseriesId)
+		record = r
+		return statusCode, err
+	})
+
+	return statusCode, record, err
+}
+
+func (c *intercepted) Search(term string, start int, limit int) (int, model.Metadata, error) {
+	return c.SearchContext(context.Background(), term, start, limit)
+}
+
+func (c *intercepted) SearchContext(ctx context.Context, term string, start int, limit int) (int, model.Metadata, error) {
+	return c.metadata(ctx, "Search", OperationSearch, []interface{}{term, start, limit}, func(ctx context.Context) (int, model.Metadata, error) {
+		return c.next.SearchContext(ctx, term, start, limit)
+	})
+}
+
+func (c *intercepted) GetData(datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error) {
+	return c.GetDataContext(context.Background(), datasetId, timeseriesId, opts...)
+}
+
+func (c *intercepted) GetDataContext(ctx context.Context, datasetId string, timeseriesId string, opts ...DataOption) (int, model.Data, error) {
+	var data model.Data
+	statusCode, err := c.call(ctx, "GetData", OperationData, []interface{}{datasetId, timeseriesId, dataParams(opts)}, func(ctx context.Context) (int, error) {
+		statusCode, d, err := c.next.GetDataContext(ctx, datasetId, timeseriesId, opts...)
+		data = d
+		return statusCode, err
+	})
+
+	return statusCode, data, err
+}
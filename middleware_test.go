@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func middlewareTransport() *httpmock.MockTransport {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder("GET", "http://foo.com/search?limit=10&q=gdp&start=0",
+		httpmock.NewStringResponder(200, `{"totalItems": 3}`))
+	transport.RegisterResponder("GET", "http://foo.com/dataset/ukea/timeseries/none/data",
+		httpmock.NewStringResponder(404, "not found"))
+
+	return transport
+}
+
+func recorder(name string, calls *[]string) Middleware {
+	return Intercept(func(ctx context.Context, call Call, invoke Invoker) (int, error) {
+		*calls = append(*calls, name+" before "+call.Method)
+		statusCode, err := invoke(ctx)
+		*calls = append(*calls, name+" after "+call.Method)
+
+		return statusCode, err
+	})
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(middlewareTransport()),
+		WithMiddleware(recorder("outer", &calls)),
+		WithMiddleware(recorder("inner", &calls)),
+	)
+
+	assert.Equal(t, M3(200, model.Metadata{TotalItems: 3}, nil), M3(client.Search("gdp", 0, 10)))
+	assert.Equal(t, []string{"outer before Search", "inner before Search", "inner after Search", "outer after Search"}, calls)
+}
+
+func TestInterceptCanAnswer(t *testing.T) {
+	var invoked bool
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(middlewareTransport()),
+		WithMiddleware(Intercept(func(ctx context.Context, call Call, invoke Invoker) (int, error) {
+			invoked = true
+			return 429, &StatusError{Path: "/ops/ping", StatusCode: 429}
+		})),
+	)
+
+	statusCode, err := client.Ping()
+
+	assert.True(t, invoked)
+	assert.Equal(t, 429, statusCode)
+	assert.Equal(t, &StatusError{Path: "/ops/ping", StatusCode: 429}, err)
+}
+
+func TestTiming(t *testing.T) {
+	var mu sync.Mutex
+	var observed []Call
+	var statusCodes []int
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(middlewareTransport()),
+		WithLogger(logging.Nop()),
+		WithMiddleware(Timing(func(call Call, statusCode int, err error, elapsed time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			observed = append(observed, call)
+			statusCodes = append(statusCodes, statusCode)
+		})),
+	)
+
+	client.SearchContext(context.Background(), "gdp", 0, 10)
+	client.GetData("ukea", "none", DataFrom("2016"))
+
+	assert.Equal(t, []Call{
+		{Method: "Search", Operation: OperationSearch, Args: []interface{}{"gdp", 0, 10}},
+		{Method: "GetData", Operation: OperationData, Args: []interface{}{"ukea", "none", map[string]string{"from": "2016"}}},
+	}, observed)
+	assert.Equal(t, []int{200, 404}, statusCodes)
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(middlewareTransport()),
+		WithLogger(logging.Nop()),
+		WithMiddleware(Logging(logging.NewJSON(&buf, logging.LevelInfo))),
+	)
+
+	client.Search("gdp", 0, 10)
+	statusCode, _, err := client.GetData("ukea", "none")
+
+	assert.Equal(t, 404, statusCode)
+	assert.True(t, IsNotFound(err))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var search, data struct {
+		Level string
+		Event string
+		Data  map[string]interface{}
+	}
+	json.Unmarshal([]byte(lines[0]), &search)
+	json.Unmarshal([]byte(lines[1]), &data)
+
+	assert.Equal(t, "info", search.Level)
+	assert.Equal(t, "call", search.Event)
+	assert.Equal(t, "Search", search.Data["method"])
+	assert.Equal(t, []interface{}{"gdp", 0.0, 10.0}, search.Data["args"])
+	assert.Equal(t, 200.0, search.Data["status"])
+
+	assert.Equal(t, "error", data.Level)
+	assert.Equal(t, "data", data.Data["operation"])
+	assert.Equal(t, 404.0, data.Data["status"])
+	assert.Contains(t, data.Data["error"], "unexpected status 404")
+}
+
+// Custom middlewares can also embed next and override single methods.
+type searchBlocker struct {
+	ApiClient
+}
+
+func (b searchBlocker) Search(term string, start int, limit int) (int, model.Metadata, error) {
+	return b.SearchContext(context.Background(), term, start, limit)
+}
+
+func (b searchBlocker) SearchContext(ctx context.Context, term string, start int, limit int) (int, model.Metadata, error) {
+	return http.StatusForbidden, model.Metadata{}, nil
+}
+
+func TestEmbeddingMiddleware(t *testing.T) {
+	client := NewApiClient(
+		WithBaseUrl("http://foo.com"),
+		WithTransport(middlewareTransport()),
+		WithMiddleware(func(next ApiClient) ApiClient { return searchBlocker{next} }),
+	)
+
+	assert.Equal(t, M3(403, model.Metadata{}, nil), M3(client.Search("gdp", 0, 10)))
+}
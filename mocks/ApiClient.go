@@ -0,0 +1,159 @@
+// Package mocks provides testify mocks of the client interfaces. They are
+// maintained by hand rather than generated, so that GetData's options are
+// recorded as one argument; when client.ApiClient changes, update ApiClient
+// to match. A compile-time assertion fails the build until it does.
+package mocks
+
+import (
+	"context"
+
+	client "github.com/ONSdigital/dp-apipoc-client"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/mock"
+)
+
+// ApiClient is a testify mock of client.ApiClient, and so of each of
+// OpsClient, CatalogueClient, SearchClient and DataClient. Every method
+// records its arguments in order; the DataOptions given to GetData and
+// GetDataContext are recorded as a single []client.DataOption argument,
+// which is best matched with mock.Anything.
+//
+//	api := new(mocks.ApiClient)
+//	api.On("Search", "gdp", 0, 10).Return(200, model.Metadata{TotalItems: 1}, nil)
+type ApiClient struct {
+	mock.Mock
+}
+
+var _ client.ApiClient = (*ApiClient)(nil)
+
+func (m *ApiClient) Ping() (int, error) {
+	ret := m.Called()
+
+	return ret.Int(0), ret.Error(1)
+}
+
+func (m *ApiClient) PingContext(ctx context.Context) (int, error) {
+	ret := m.Called(ctx)
+
+	return ret.Int(0), ret.Error(1)
+}
+
+func (m *ApiClient) Status() (int, model.Status, error) {
+	ret := m.Called()
+
+	return ret.Int(0), ret.Get(1).(model.Status), ret.Error(2)
+}
+
+func (m *ApiClient) StatusContext(ctx context.Context) (int, model.Status, error) {
+	ret := m.Called(ctx)
+
+	return ret.Int(0), ret.Get(1).(model.Status), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasets(start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetsContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetsForId(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(datasetId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetsForIdContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, datasetId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetsForTimeseries(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(timeseriesId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetsForTimeseriesContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, timeseriesId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseries(start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseriesContext(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseriesForId(timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(timeseriesId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseriesForIdContext(ctx context.Context, timeseriesId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, timeseriesId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseriesForDataset(datasetId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(datasetId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetTimeseriesForDatasetContext(ctx context.Context, datasetId string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, datasetId, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetDataset(datasetId string, timeseriesId string) (int, model.Record, error) {
+	ret := m.Called(datasetId, timeseriesId)
+
+	return ret.Int(0), ret.Get(1).(model.Record), ret.Error(2)
+}
+
+func (m *ApiClient) GetDatasetContext(ctx context.Context, datasetId string, timeseriesId string) (int, model.Record, error) {
+	ret := m.Called(ctx, datasetId, timeseriesId)
+
+	return ret.Int(0), ret.Get(1).(model.Record), ret.Error(2)
+}
+
+func (m *ApiClient) Search(term string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(term, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) SearchContext(ctx context.Context, term string, start int, limit int) (int, model.Metadata, error) {
+	ret := m.Called(ctx, term, start, limit)
+
+	return ret.Int(0), ret.Get(1).(model.Metadata), ret.Error(2)
+}
+
+func (m *ApiClient) GetData(datasetId string, timeseriesId string, opts ...client.DataOption) (int, model.Data, error) {
+	ret := m.Called(datasetId, timeseriesId, opts)
+
+	return ret.Int(0), ret.Get(1).(model.Data), ret.Error(2)
+}
+
+func (m *ApiClient) GetDataContext(ctx context.Context, datasetId string, timeseriesId string, opts ...client.DataOption) (int, model.Data, error) {
+	ret := m.Called(ctx, datasetId, timeseriesId, opts)
+
+	return ret.Int(0), ret.Get(1).(model.Data), ret.Error(2)
+}
@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	client "github.com/ONSdigital/dp-apipoc-client"
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func search(c client.SearchClient) (int, model.Metadata, error) {
+	return c.Search("gdp", 0, 10)
+}
+
+func TestApiClient(t *testing.T) {
+	api := new(ApiClient)
+	failure := errors.New("boom")
+
+	api.On("Search", "gdp", 0, 10).Return(200, model.Metadata{TotalItems: 1}, nil)
+	api.On("PingContext", mock.Anything).Return(0, failure)
+	api.On("GetData", "ukea", "abmi", mock.Anything).Return(200, model.Data{DataType: "timeseries"}, nil)
+
+	statusCode, metadata, err := search(api)
+	assert.Equal(t, 200, statusCode)
+	assert.Equal(t, 1, metadata.TotalItems)
+	assert.Nil(t, err)
+
+	statusCode, err = api.PingContext(context.Background())
+	assert.Equal(t, 0, statusCode)
+	assert.Equal(t, failure, err)
+
+	_, data, _ := api.GetData("ukea", "abmi", client.DataFrom("2016"))
+	assert.Equal(t, "timeseries", data.DataType)
+
+	api.AssertExpectations(t)
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Filter returns a copy of d keeping only the periods of freq, or of every
+// frequency if freq is FrequencyUnknown, that start in [from, to). A zero
+// from or to leaves that end of the range open. Periods whose dates cannot
+// be parsed are dropped when a range is given; values are kept as they are,
+// parseable or not. d itself is not modified.
+func (d Data) Filter(freq Frequency, from time.Time, to time.Time) Data {
+	filtered := d
+	filtered.Years = filterPeriods(d.Years, freq == FrequencyUnknown || freq == Annual, from, to)
+	filtered.Quarters = filterPeriods(d.Quarters, freq == FrequencyUnknown || freq == Quarterly, from, to)
+	filtered.Months = filterPeriods(d.Months, freq == FrequencyUnknown || freq == Monthly, from, to)
+
+	return filtered
+}
+
+func filterPeriods(periods *[]Period, keep bool, from time.Time, to time.Time) *[]Period {
+	if periods == nil {
+		return nil
+	}
+
+	kept := []Period{}
+	if !keep {
+		return &kept
+	}
+
+	for _, p := range *periods {
+		if from.IsZero() && to.IsZero() {
+			kept = append(kept, p)
+			continue
+		}
+
+		_, start, _, err := p.parseDate()
+		if err != nil {
+			continue
+		}
+
+		if (from.IsZero() || !start.Before(from)) && (to.IsZero() || start.Before(to)) {
+			kept = append(kept, p)
+		}
+	}
+
+	return &kept
+}
@@ -0,0 +1,241 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	FrequencyUnknown Frequency = iota
+	Annual
+	Quarterly
+	Monthly
+)
+
+func (f Frequency) String() string {
+	switch f {
+	case Annual:
+		return "annual"
+	case Quarterly:
+		return "quarterly"
+	case Monthly:
+		return "monthly"
+	default:
+		return "unknown"
+	}
+}
+
+// ValueStatus distinguishes a reported value from one that was left blank or
+// withheld by ONS.
+type ValueStatus int
+
+const (
+	Present ValueStatus = iota
+	Missing
+	Suppressed
+)
+
+func (s ValueStatus) String() string {
+	switch s {
+	case Present:
+		return "present"
+	case Missing:
+		return "missing"
+	case Suppressed:
+		return "suppressed"
+	default:
+		return "unknown"
+	}
+}
+
+// Observation is a Period with its date and value parsed. Start is the first
+// instant of the period and End the first instant of the next, both in UTC.
+// Value is zero unless Status is Present; Raw keeps the value as published
+// for callers that need exact decimals.
+type Observation struct {
+	Frequency     Frequency
+	Start         time.Time
+	End           time.Time
+	Label         string
+	Value         float64
+	Status        ValueStatus
+	Raw           string
+	SourceDataset string
+	UpdateDate    time.Time
+}
+
+// Period formats the start of o the way the API writes period dates, e.g.
+// "2016", "2016 Q3" or "2017 JAN".
+func (o Observation) Period() string {
+	return FormatPeriod(o.Frequency, o.Start)
+}
+
+// FormatPeriod is the inverse of ParsePeriod.
+func FormatPeriod(freq Frequency, start time.Time) string {
+	switch freq {
+	case Annual:
+		return strconv.Itoa(start.Year())
+	case Quarterly:
+		return fmt.Sprintf("%d Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case Monthly:
+		return fmt.Sprintf("%d %s", start.Year(), strings.ToUpper(start.Month().String()[:3]))
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+var months = map[string]time.Month{
+	"JAN": time.January,
+	"FEB": time.February,
+	"MAR": time.March,
+	"APR": time.April,
+	"MAY": time.May,
+	"JUN": time.June,
+	"JUL": time.July,
+	"AUG": time.August,
+	"SEP": time.September,
+	"OCT": time.October,
+	"NOV": time.November,
+	"DEC": time.December,
+}
+
+// ParsePeriod parses the period dates used by the API: "2016", "2016 Q3"
+// and "2017 JAN". Month names are matched on their first three letters,
+// ignoring case.
+func ParsePeriod(date string) (Frequency, time.Time, time.Time, error) {
+	fields := strings.Fields(date)
+	if len(fields) == 0 || len(fields) > 2 {
+		return FrequencyUnknown, time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", date)
+	}
+
+	year, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return FrequencyUnknown, time.Time{}, time.Time{}, fmt.Errorf("invalid period %q: bad year", date)
+	}
+
+	if len(fields) == 1 {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return Annual, start, start.AddDate(1, 0, 0), nil
+	}
+
+	sub := strings.ToUpper(fields[1])
+
+	if len(sub) == 2 && sub[0] == 'Q' && sub[1] >= '1' && sub[1] <= '4' {
+		quarter := int(sub[1] - '0')
+		start := time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, time.UTC)
+		return Quarterly, start, start.AddDate(0, 3, 0), nil
+	}
+
+	if len(sub) >= 3 {
+		if month, ok := months[sub[:3]]; ok {
+			start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+			return Monthly, start, start.AddDate(0, 1, 0), nil
+		}
+	}
+
+	return FrequencyUnknown, time.Time{}, time.Time{}, fmt.Errorf("invalid period %q: bad quarter or month", date)
+}
+
+// ParseValue parses a published value. Blank values and "-" are Missing;
+// "x", "..", "[x]" and "[c]" are the markers ONS uses for Suppressed values.
+// Thousands separators are ignored.
+func ParseValue(value string) (float64, ValueStatus, error) {
+	trimmed := strings.TrimSpace(value)
+
+	switch strings.ToLower(trimmed) {
+	case "", "-":
+		return 0, Missing, nil
+	case "x", "..", "[x]", "[c]":
+		return 0, Suppressed, nil
+	}
+
+	f, err := strconv.ParseFloat(strings.Replace(trimmed, ",", "", -1), 64)
+	if err != nil {
+		return 0, Missing, fmt.Errorf("invalid value %q", value)
+	}
+
+	return f, Present, nil
+}
+
+// Observation parses p. When the date cannot be parsed the year, quarter and
+// month fields are tried instead.
+func (p Period) Observation() (Observation, error) {
+	freq, start, end, err := p.parseDate()
+	if err != nil {
+		return Observation{}, err
+	}
+
+	value, status, err := ParseValue(p.Value)
+	if err != nil {
+		return Observation{}, fmt.Errorf("period %q: %v", p.PeriodDate, err)
+	}
+
+	return Observation{
+		Frequency:     freq,
+		Start:         start,
+		End:           end,
+		Label:         p.Label,
+		Value:         value,
+		Status:        status,
+		Raw:           p.Value,
+		SourceDataset: p.SourceDataset,
+		UpdateDate:    p.UpdateDate,
+	}, nil
+}
+
+// Periods returns the periods published for freq, or nil if there are none.
+func (d Data) Periods(freq Frequency) []Period {
+	var periods *[]Period
+
+	switch freq {
+	case Annual:
+		periods = d.Years
+	case Quarterly:
+		periods = d.Quarters
+	case Monthly:
+		periods = d.Months
+	}
+
+	if periods == nil {
+		return nil
+	}
+
+	return *periods
+}
+
+// Observations parses every period published for freq, stopping at the first
+// that cannot be parsed.
+func (d Data) Observations(freq Frequency) ([]Observation, error) {
+	periods := d.Periods(freq)
+	observations := make([]Observation, 0, len(periods))
+
+	for _, p := range periods {
+		o, err := p.Observation()
+		if err != nil {
+			return nil, err
+		}
+
+		observations = append(observations, o)
+	}
+
+	return observations, nil
+}
+
+// parseDate parses PeriodDate, falling back to the year, quarter and month
+// fields when the date is missing or malformed.
+func (p Period) parseDate() (Frequency, time.Time, time.Time, error) {
+	freq, start, end, err := ParsePeriod(p.PeriodDate)
+	if err != nil {
+		var fallbackErr error
+		freq, start, end, fallbackErr = ParsePeriod(strings.TrimSpace(p.PeriodYear + " " + p.Quarter + p.PeriodMonth))
+		if fallbackErr != nil {
+			return freq, start, end, err
+		}
+	}
+
+	return freq, start, end, nil
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParsePeriodAnnual(t *testing.T) {
+	freq, start, end, err := ParsePeriod("1987")
+
+	assert.Nil(t, err)
+	assert.Equal(t, Annual, freq)
+	assert.Equal(t, date(1987, time.January), start)
+	assert.Equal(t, date(1988, time.January), end)
+}
+
+func TestParsePeriodQuarterly(t *testing.T) {
+	freq, start, end, err := ParsePeriod("2016 Q4")
+
+	assert.Nil(t, err)
+	assert.Equal(t, Quarterly, freq)
+	assert.Equal(t, date(2016, time.October), start)
+	assert.Equal(t, date(2017, time.January), end)
+}
+
+func TestParsePeriodMonthly(t *testing.T) {
+	for _, s := range []string{"2017 JAN", "2017 Jan", "2017 January"} {
+		freq, start, end, err := ParsePeriod(s)
+
+		assert.Nil(t, err)
+		assert.Equal(t, Monthly, freq)
+		assert.Equal(t, date(2017, time.January), start)
+		assert.Equal(t, date(2017, time.February), end)
+	}
+}
+
+func TestParsePeriodInvalid(t *testing.T) {
+	for _, s := range []string{"", "Q1", "2016 Q5", "2016 FOO", "2016 Q1 X"} {
+		_, _, _, err := ParsePeriod(s)
+
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	value, status, err := ParseValue("-1,234.5")
+	assert.Nil(t, err)
+	assert.Equal(t, Present, status)
+	assert.Equal(t, -1234.5, value)
+
+	for _, s := range []string{"", " ", "-"} {
+		_, status, err = ParseValue(s)
+		assert.Nil(t, err)
+		assert.Equal(t, Missing, status)
+	}
+
+	for _, s := range []string{"x", "..", "[x]", "[c]"} {
+		_, status, err = ParseValue(s)
+		assert.Nil(t, err)
+		assert.Equal(t, Suppressed, status)
+	}
+
+	_, _, err = ParseValue("n/a")
+	assert.NotNil(t, err)
+}
+
+func TestPeriodObservationFallsBackToFields(t *testing.T) {
+	p := Period{PeriodDate: "", PeriodYear: "2016", Quarter: "Q3", Value: "798", Label: "2016 Q3"}
+
+	o, err := p.Observation()
+
+	assert.Nil(t, err)
+	assert.Equal(t, Quarterly, o.Frequency)
+	assert.Equal(t, date(2016, time.July), o.Start)
+	assert.Equal(t, 798.0, o.Value)
+	assert.Equal(t, "798", o.Raw)
+}
+
+func TestDataObservations(t *testing.T) {
+	d := Data{
+		Years: &[]Period{
+			{PeriodDate: "1987", Value: "5119", SourceDataset: "UKEA"},
+			{PeriodDate: "2015", Value: "", SourceDataset: "UKEA"},
+		},
+	}
+
+	observations, err := d.Observations(Annual)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(observations))
+	assert.Equal(t, 5119.0, observations[0].Value)
+	assert.Equal(t, Missing, observations[1].Status)
+	assert.Equal(t, "UKEA", observations[1].SourceDataset)
+
+	observations, err = d.Observations(Monthly)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(observations))
+}
+
+func TestFormatPeriodRoundTrips(t *testing.T) {
+	for _, s := range []string{"1987", "2016 Q1", "2016 Q4", "2017 JAN", "2017 SEP"} {
+		freq, start, _, err := ParsePeriod(s)
+
+		assert.Nil(t, err)
+		assert.Equal(t, s, FormatPeriod(freq, start))
+	}
+}
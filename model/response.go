@@ -6,8 +6,9 @@ import (
 )
 
 type Response struct {
-	Success *http.Response
-	Failure error
+	Success  *http.Response
+	Failure  error
+	Attempts int
 }
 
 type Status struct {
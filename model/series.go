@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Series is one frequency of a timeseries with its observations in
+// chronological order.
+type Series struct {
+	CDID         string
+	Title        string
+	DatasetId    string
+	DataUnit     string
+	PreUnit      string
+	Source       string
+	Frequency    Frequency
+	Observations []Observation
+}
+
+// HighestFrequency returns the most frequent of the non-empty Years, Quarters
+// and Months, or FrequencyUnknown if all are empty.
+func (d Data) HighestFrequency() Frequency {
+	for _, freq := range []Frequency{Monthly, Quarterly, Annual} {
+		if len(d.Periods(freq)) > 0 {
+			return freq
+		}
+	}
+
+	return FrequencyUnknown
+}
+
+// Series builds a Series for freq, or for the highest available frequency if
+// freq is FrequencyUnknown.
+func (d Data) Series(freq Frequency) (Series, error) {
+	if freq == FrequencyUnknown {
+		freq = d.HighestFrequency()
+	}
+
+	observations, err := d.Observations(freq)
+	if err != nil {
+		return Series{}, err
+	}
+
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].Start.Before(observations[j].Start)
+	})
+
+	s := Series{Frequency: freq, Observations: observations}
+
+	if d.Description != nil {
+		s.CDID = d.Description.CDID
+		s.Title = d.Description.Title
+		s.DatasetId = d.Description.DatasetId
+		s.DataUnit = d.Description.DataUnit
+		s.PreUnit = d.Description.PreUnit
+		s.Source = d.Description.Source
+	}
+
+	return s, nil
+}
+
+func (s Series) Len() int {
+	return len(s.Observations)
+}
+
+// Between returns the observations starting in [from, to). A zero from or to
+// leaves that end of the range open. The observations are shared with s.
+func (s Series) Between(from time.Time, to time.Time) Series {
+	lo := 0
+	if !from.IsZero() {
+		lo = sort.Search(len(s.Observations), func(i int) bool {
+			return !s.Observations[i].Start.Before(from)
+		})
+	}
+
+	hi := len(s.Observations)
+	if !to.IsZero() {
+		hi = sort.Search(len(s.Observations), func(i int) bool {
+			return !s.Observations[i].Start.Before(to)
+		})
+	}
+
+	if hi < lo {
+		hi = lo
+	}
+
+	sliced := s
+	sliced.Observations = s.Observations[lo:hi]
+
+	return sliced
+}
+
+// BetweenPeriods is Between with the bounds given as period dates, e.g.
+// BetweenPeriods("2015 Q1", "2016 Q4") includes both quarters. Either bound
+// may be empty.
+func (s Series) BetweenPeriods(from string, to string) (Series, error) {
+	var start, end time.Time
+
+	if len(from) > 0 {
+		_, periodStart, _, err := ParsePeriod(from)
+		if err != nil {
+			return Series{}, fmt.Errorf("invalid from: %v", err)
+		}
+		start = periodStart
+	}
+
+	if len(to) > 0 {
+		_, _, periodEnd, err := ParsePeriod(to)
+		if err != nil {
+			return Series{}, fmt.Errorf("invalid to: %v", err)
+		}
+		end = periodEnd
+	}
+
+	return s.Between(start, end), nil
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testData() Data {
+	return Data{
+		Years: &[]Period{
+			{PeriodDate: "2016", Value: "10"},
+			{PeriodDate: "2015", Value: "9"},
+		},
+		Quarters: &[]Period{
+			{PeriodDate: "2016 Q2", Value: "3"},
+			{PeriodDate: "2015 Q4", Value: "1"},
+			{PeriodDate: "2016 Q1", Value: "2"},
+			{PeriodDate: "2016 Q3", Value: "4"},
+		},
+		Months: &[]Period{},
+		Description: &Description{
+			CDID:      "CPCM",
+			Title:     "Public corporations Net Borrowing",
+			DatasetId: "UKEA",
+			DataUnit:  "m",
+			PreUnit:   "£",
+			Source:    "ONS",
+		},
+	}
+}
+
+func labels(s Series) []string {
+	var l []string
+	for _, o := range s.Observations {
+		l = append(l, o.Raw)
+	}
+	return l
+}
+
+func TestHighestFrequency(t *testing.T) {
+	assert.Equal(t, Quarterly, testData().HighestFrequency())
+	assert.Equal(t, FrequencyUnknown, Data{}.HighestFrequency())
+}
+
+func TestSeriesIsSortedWithMetadata(t *testing.T) {
+	s, err := testData().Series(FrequencyUnknown)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Quarterly, s.Frequency)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, labels(s))
+	assert.Equal(t, "CPCM", s.CDID)
+	assert.Equal(t, "m", s.DataUnit)
+	assert.Equal(t, "£", s.PreUnit)
+	assert.Equal(t, "ONS", s.Source)
+	assert.Equal(t, "UKEA", s.DatasetId)
+}
+
+func TestSeriesForFrequency(t *testing.T) {
+	s, err := testData().Series(Annual)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"9", "10"}, labels(s))
+
+	s, err = testData().Series(Monthly)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSeriesBetween(t *testing.T) {
+	s, _ := testData().Series(Quarterly)
+
+	assert.Equal(t, []string{"2", "3"}, labels(s.Between(date(2016, time.January), date(2016, time.July))))
+	assert.Equal(t, []string{"3", "4"}, labels(s.Between(date(2016, time.April), time.Time{})))
+	assert.Equal(t, []string{"1"}, labels(s.Between(time.Time{}, date(2016, time.January))))
+	assert.Equal(t, 0, s.Between(date(2017, time.January), date(2016, time.January)).Len())
+}
+
+func TestSeriesBetweenPeriods(t *testing.T) {
+	s, _ := testData().Series(Quarterly)
+
+	sliced, err := s.BetweenPeriods("2016 Q1", "2016 Q2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "3"}, labels(sliced))
+
+	sliced, err = s.BetweenPeriods("2016", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "3", "4"}, labels(sliced))
+
+	_, err = s.BetweenPeriods("foo", "")
+	assert.NotNil(t, err)
+}
+
+func TestDataFilter(t *testing.T) {
+	d := testData()
+
+	filtered := d.Filter(Quarterly, date(2016, time.January), date(2016, time.July))
+
+	assert.Equal(t, []Period{}, *filtered.Years)
+	assert.Equal(t, []Period{{PeriodDate: "2016 Q2", Value: "3"}, {PeriodDate: "2016 Q1", Value: "2"}}, *filtered.Quarters)
+	assert.Equal(t, []Period{}, *filtered.Months)
+	assert.Equal(t, d.Description, filtered.Description)
+	assert.Equal(t, 4, len(*d.Quarters))
+
+	filtered = d.Filter(FrequencyUnknown, date(2016, time.January), time.Time{})
+
+	assert.Equal(t, []Period{{PeriodDate: "2016", Value: "10"}}, *filtered.Years)
+	assert.Equal(t, 3, len(*filtered.Quarters))
+	assert.Nil(t, Data{}.Filter(Annual, time.Time{}, time.Time{}).Years)
+}
+
+func TestDataFilterKeepsUnparseableValues(t *testing.T) {
+	d := Data{Months: &[]Period{
+		{PeriodDate: "2016 JAN", Value: "1"},
+		{PeriodDate: "2016 FEB", Value: "n/a"},
+		{PeriodDate: "", PeriodYear: "2016", PeriodMonth: "MAR", Value: "x"},
+		{PeriodDate: "bad", Value: "4"},
+		{PeriodDate: "2016 APR", Value: "5"},
+	}}
+
+	filtered := d.Filter(Monthly, date(2016, time.February), date(2016, time.April))
+
+	assert.Equal(t, []Period{
+		{PeriodDate: "2016 FEB", Value: "n/a"},
+		{PeriodDate: "", PeriodYear: "2016", PeriodMonth: "MAR", Value: "x"},
+	}, *filtered.Months)
+}
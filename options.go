@@ -0,0 +1,138 @@
+package client
+
+import (
+	nethttp "net/http"
+
+	"github.com/ONSdigital/dp-apipoc-client/cache"
+	"github.com/ONSdigital/dp-apipoc-client/http"
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/ONSdigital/dp-apipoc-client/metrics"
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+type Option func(*config)
+
+type Operation = http.Operation
+
+const (
+	OperationPing     = http.OperationPing
+	OperationStatus   = http.OperationStatus
+	OperationMetadata = http.OperationMetadata
+	OperationSearch   = http.OperationSearch
+	OperationData     = http.OperationData
+)
+
+type config struct {
+	logger      logging.Logger
+	cache       *cache.Cache
+	coalescer   *Coalescer
+	middlewares []Middleware
+	metrics     *metrics.Registry
+	httpOptions []http.Option
+}
+
+func httpOption(opt http.Option) Option {
+	return func(c *config) {
+		c.httpOptions = append(c.httpOptions, opt)
+	}
+}
+
+// WithBaseUrl sets the API server root, overriding API_SERVER_ROOT.
+func WithBaseUrl(url string) Option {
+	return httpOption(http.WithBaseUrl(url))
+}
+
+// WithHttpClient replaces the default *http.Client, whose timeout is 10 seconds.
+func WithHttpClient(client *nethttp.Client) Option {
+	return httpOption(http.WithClient(client))
+}
+
+func WithTransport(transport nethttp.RoundTripper) Option {
+	return httpOption(http.WithTransport(transport))
+}
+
+// WithTransportMiddleware layers http.Middleware over the transport, beneath
+// the hystrix command, e.g. http.RequestID() or http.Dump(os.Stderr, false).
+func WithTransportMiddleware(middlewares ...http.Middleware) Option {
+	return httpOption(http.WithMiddleware(middlewares...))
+}
+
+// WithHystrixConfig sets the hystrix command settings for every operation
+// not configured with WithOperationHystrixConfig. Zero fields take the
+// hystrix defaults.
+func WithHystrixConfig(commandConfig hystrix.CommandConfig) Option {
+	return httpOption(http.WithCommandConfig(commandConfig))
+}
+
+// WithOperationHystrixConfig sets the hystrix command settings for a single
+// operation, e.g. a longer timeout for OperationSearch.
+func WithOperationHystrixConfig(op Operation, commandConfig hystrix.CommandConfig) Option {
+	return httpOption(http.WithOperationConfig(op, commandConfig))
+}
+
+// WithHystrixCommandName shares named circuits between clients. Without it
+// every client gets its own set of circuits. Hystrix commands are global and
+// never removed, so clients are meant to live for the whole process; code
+// that creates clients repeatedly should give them a fixed name.
+func WithHystrixCommandName(name string) Option {
+	return httpOption(http.WithCommandName(name))
+}
+
+// WithLogger replaces the default logger, which writes info and higher
+// events to stdout as JSON. See logging.Logger for the events logged.
+func WithLogger(logger logging.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+		c.httpOptions = append(c.httpOptions, http.WithLogger(logger))
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return httpOption(http.WithUserAgent(userAgent))
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key string, value string) Option {
+	return httpOption(http.WithHeader(key, value))
+}
+
+// WithRetryPolicy retries failed requests, e.g. client.WithRetryPolicy(http.NewBackoffPolicy(3)).
+// Retries happen inside the hystrix command, so its timeout must allow for them.
+func WithRetryPolicy(policy http.RetryPolicy) Option {
+	return httpOption(http.WithRetryPolicy(policy))
+}
+
+// WithCache serves metadata, search and data responses from c while they
+// are fresh, and revalidates expired entries with conditional requests. Keep
+// a reference to c to read its Stats or invalidate entries.
+func WithCache(c *cache.Cache) Option {
+	return func(cfg *config) {
+		cfg.cache = c
+	}
+}
+
+// WithCoalescer replaces the client's own Coalescer, e.g. to read how many
+// calls it has coalesced.
+func WithCoalescer(c *Coalescer) Option {
+	return func(cfg *config) {
+		cfg.coalescer = c
+	}
+}
+
+// WithMiddleware wraps the client in middlewares, the first outermost. It
+// may be given more than once.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithMetrics records per-operation request counts, failures by class,
+// latency, retries, fallbacks, timeouts, short circuits and cache hits in
+// registry. Serve registry to expose them to Prometheus.
+func WithMetrics(registry *metrics.Registry) Option {
+	return func(c *config) {
+		c.metrics = registry
+		c.httpOptions = append(c.httpOptions, http.WithMetrics(registry))
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/logging"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestNewApiClientWithBaseUrlAndTransport(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"HEAD",
+		"http://baz.com/ops/ping",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, ""), nil
+		},
+	)
+
+	client := NewApiClient(WithBaseUrl("http://baz.com"), WithTransport(transport))
+
+	assert.Equal(t, M(200, nil), M(client.Ping()))
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+}
+
+func TestNewApiClientWithUserAgentAndHeaders(t *testing.T) {
+	var received http.Header
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"HEAD",
+		"http://baz.com/ops/ping",
+		func(req *http.Request) (*http.Response, error) {
+			received = req.Header
+			return httpmock.NewStringResponse(200, ""), nil
+		},
+	)
+
+	client := NewApiClient(
+		WithBaseUrl("http://baz.com"),
+		WithHttpClient(&http.Client{Transport: transport}),
+		WithUserAgent("dp-test/1.0"),
+		WithHeader("X-Request-Id", "abc123"),
+	)
+
+	assert.Equal(t, M(200, nil), M(client.Ping()))
+	assert.Equal(t, "dp-test/1.0", received.Get("User-Agent"))
+	assert.Equal(t, "abc123", received.Get("X-Request-Id"))
+}
+
+func TestNewApiClientWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		"HEAD",
+		"http://baz.com/ops/ping",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(500, ""), nil
+		},
+	)
+
+	client := NewApiClient(
+		WithBaseUrl("http://baz.com"),
+		WithTransport(transport),
+		WithLogger(logging.NewJSON(&buf, logging.LevelDebug)),
+	)
+
+	statusCode, err := client.Ping()
+
+	assert.Equal(t, 500, statusCode)
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), `"event":"api_error"`)
+	assert.Contains(t, buf.String(), "unexpected status 500")
+}
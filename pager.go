@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+const defaultPageSize = 20
+
+// PageFunc fetches one page of metadata. The ApiClient context methods
+// GetDatasetsContext and GetTimeseriesContext can be used directly; the
+// others need a closure binding their id or search term, e.g.
+//
+//	func(ctx context.Context, start, limit int) (int, model.Metadata, error) {
+//		return c.SearchContext(ctx, "inflation", start, limit)
+//	}
+type PageFunc func(ctx context.Context, start int, limit int) (int, model.Metadata, error)
+
+type PagerOption func(*Pager)
+
+// WithPageSize sets the limit requested for each page. The default is 20.
+func WithPageSize(size int) PagerOption {
+	return func(p *Pager) {
+		if size > 0 {
+			p.pageSize = size
+		}
+	}
+}
+
+// WithPrefetch fetches the next page in the background while the current
+// one is being consumed.
+func WithPrefetch() PagerOption {
+	return func(p *Pager) {
+		p.prefetch = true
+	}
+}
+
+// WithStart begins paging at the given index rather than zero.
+func WithStart(start int) PagerOption {
+	return func(p *Pager) {
+		p.start = start
+	}
+}
+
+type page struct {
+	metadata model.Metadata
+	err      error
+}
+
+// Pager walks every record of a paged metadata endpoint, fetching pages
+// lazily as Next is called. Paging stops at the first error. Callers that
+// stop before Next returns false should call Close.
+type Pager struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	fetch    PageFunc
+	pageSize int
+	prefetch bool
+
+	start   int
+	items   []model.Record
+	index   int
+	done    bool
+	err     error
+	pending chan page
+}
+
+func NewPager(ctx context.Context, fetch PageFunc, opts ...PagerOption) *Pager {
+	p := &Pager{
+		fetch:    fetch,
+		pageSize: defaultPageSize,
+		index:    -1,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	return p
+}
+
+// Next advances to the next record, fetching a new page when required. It
+// returns false once all records have been read or an error occurs.
+func (p *Pager) Next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.items) {
+		if p.done {
+			p.Close()
+			return false
+		}
+
+		if !p.nextPage() {
+			p.Close()
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Pager) Record() model.Record {
+	if p.index < 0 || p.index >= len(p.items) {
+		return model.Record{}
+	}
+
+	return p.items[p.index]
+}
+
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// Close stops any background prefetch. It is safe to call more than once.
+func (p *Pager) Close() {
+	p.cancel()
+}
+
+func (p *Pager) nextPage() bool {
+	var result page
+	if p.pending != nil {
+		result = <-p.pending
+		p.pending = nil
+	} else {
+		result = p.fetchPage(p.start)
+	}
+
+	if result.err != nil {
+		p.err = result.err
+		return false
+	}
+
+	var items []model.Record
+	if result.metadata.Items != nil {
+		items = *result.metadata.Items
+	}
+
+	p.items = items
+	p.index = 0
+	p.start += len(items)
+
+	if len(items) == 0 || p.start >= result.metadata.TotalItems {
+		p.done = true
+	} else if p.prefetch {
+		p.pending = make(chan page, 1)
+		go func(pending chan page, start int) {
+			pending <- p.fetchPage(start)
+		}(p.pending, p.start)
+	}
+
+	return len(items) > 0
+}
+
+func (p *Pager) fetchPage(start int) page {
+	_, metadata, err := p.fetch(p.ctx, start, p.pageSize)
+
+	return page{metadata: metadata, err: err}
+}
+
+// All returns an iterator over every record of a paged metadata endpoint.
+// On failure it yields a zero Record with the error and stops.
+func All(ctx context.Context, fetch PageFunc, opts ...PagerOption) iter.Seq2[model.Record, error] {
+	return func(yield func(model.Record, error) bool) {
+		p := NewPager(ctx, fetch, opts...)
+		defer p.Close()
+
+		for p.Next() {
+			if !yield(p.Record(), nil) {
+				return
+			}
+		}
+
+		if p.err != nil {
+			yield(model.Record{}, p.err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePages struct {
+	sync.Mutex
+	total    int
+	failAt   int
+	requests []int
+}
+
+func (f *fakePages) fetch(ctx context.Context, start int, limit int) (int, model.Metadata, error) {
+	f.Lock()
+	f.requests = append(f.requests, start)
+	f.Unlock()
+
+	if f.failAt > 0 && start >= f.failAt {
+		return 500, model.Metadata{}, errors.New("boom")
+	}
+
+	items := []model.Record{}
+	for i := start; i < start+limit && i < f.total; i++ {
+		items = append(items, model.Record{RecordUri: "/" + strconv.Itoa(i)})
+	}
+
+	return 200, model.Metadata{StartIndex: start, ItemsPerPage: limit, TotalItems: f.total, Items: &items}, nil
+}
+
+func (f *fakePages) requested() []int {
+	f.Lock()
+	defer f.Unlock()
+
+	return append([]int(nil), f.requests...)
+}
+
+func TestPagerWalksAllPages(t *testing.T) {
+	pages := &fakePages{total: 7}
+
+	p := NewPager(context.Background(), pages.fetch, WithPageSize(3))
+
+	var uris []string
+	for p.Next() {
+		uris = append(uris, p.Record().RecordUri)
+	}
+
+	assert.Nil(t, p.Err())
+	assert.Equal(t, []string{"/0", "/1", "/2", "/3", "/4", "/5", "/6"}, uris)
+	assert.Equal(t, []int{0, 3, 6}, pages.requested())
+}
+
+func TestPagerWithNoResults(t *testing.T) {
+	pages := &fakePages{total: 0}
+
+	p := NewPager(context.Background(), pages.fetch)
+
+	assert.False(t, p.Next())
+	assert.Nil(t, p.Err())
+	assert.Equal(t, model.Record{}, p.Record())
+}
+
+func TestPagerStopsOnError(t *testing.T) {
+	pages := &fakePages{total: 10, failAt: 4}
+
+	p := NewPager(context.Background(), pages.fetch, WithPageSize(4))
+
+	count := 0
+	for p.Next() {
+		count++
+	}
+
+	assert.Equal(t, 4, count)
+	assert.EqualError(t, p.Err(), "boom")
+	assert.False(t, p.Next())
+}
+
+func TestPagerWithPrefetch(t *testing.T) {
+	pages := &fakePages{total: 5}
+
+	p := NewPager(context.Background(), pages.fetch, WithPageSize(2), WithPrefetch())
+
+	count := 0
+	for p.Next() {
+		count++
+	}
+
+	assert.Nil(t, p.Err())
+	assert.Equal(t, 5, count)
+	assert.Equal(t, []int{0, 2, 4}, pages.requested())
+}
+
+func TestAllRangesOverRecords(t *testing.T) {
+	pages := &fakePages{total: 5}
+
+	var uris []string
+	for record, err := range All(context.Background(), pages.fetch, WithPageSize(2), WithStart(1)) {
+		assert.Nil(t, err)
+		uris = append(uris, record.RecordUri)
+	}
+
+	assert.Equal(t, []string{"/1", "/2", "/3", "/4"}, uris)
+}
+
+func TestAllYieldsErrorAndStops(t *testing.T) {
+	pages := &fakePages{total: 10, failAt: 2}
+
+	var errs []error
+	count := 0
+	for _, err := range All(context.Background(), pages.fetch, WithPageSize(2)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		count++
+	}
+
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, len(errs))
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	pages := &fakePages{total: 100}
+
+	for range All(context.Background(), pages.fetch, WithPageSize(10), WithPrefetch()) {
+		break
+	}
+
+	assert.True(t, len(pages.requested()) <= 2)
+}
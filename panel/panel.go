@@ -0,0 +1,321 @@
+package panel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// Join decides which periods appear in a Frame.
+type Join int
+
+const (
+	// Outer keeps every period published by any series.
+	Outer Join = iota
+	// Inner keeps only the periods published by every series.
+	Inner
+)
+
+// Fill decides what goes in a cell for a period a series did not publish.
+type Fill int
+
+const (
+	// FillNone leaves gaps Missing.
+	FillNone Fill = iota
+	// FillForward carries the previous Present value of the series forward.
+	FillForward
+	// FillValue uses the value given to WithFillValue.
+	FillValue
+)
+
+type config struct {
+	join      Join
+	fill      Fill
+	fillValue float64
+	frequency model.Frequency
+}
+
+type Option func(*config)
+
+// WithJoin sets the join used to build the period axis. The default is Outer.
+func WithJoin(join Join) Option {
+	return func(c *config) {
+		c.join = join
+	}
+}
+
+// WithFillForward fills gaps with the last Present value of each series.
+func WithFillForward() Option {
+	return func(c *config) {
+		c.fill = FillForward
+	}
+}
+
+// WithFillValue fills gaps with value.
+func WithFillValue(value float64) Option {
+	return func(c *config) {
+		c.fill = FillValue
+		c.fillValue = value
+	}
+}
+
+// WithFrequency selects the frequency to align on. By default the highest
+// frequency published by every series is used.
+func WithFrequency(freq model.Frequency) Option {
+	return func(c *config) {
+		c.frequency = freq
+	}
+}
+
+// Cell is one series' value for one period. Gap is set when the series did
+// not publish the period; Status is then Missing unless the gap was filled.
+type Cell struct {
+	Value  float64
+	Status model.ValueStatus
+	Gap    bool
+}
+
+// Column is one series aligned on the frame's periods.
+type Column struct {
+	CDID      string
+	Title     string
+	DatasetId string
+	DataUnit  string
+	PreUnit   string
+	Cells     []Cell
+}
+
+// Row is every series' value for one period, in column order.
+type Row struct {
+	Period string
+	Start  time.Time
+	End    time.Time
+	Cells  []Cell
+}
+
+// Frame is a set of series aligned on a common period axis. Periods are in
+// chronological order.
+type Frame struct {
+	Frequency model.Frequency
+	starts    []time.Time
+	columns   []Column
+}
+
+var ErrNoCommonFrequency = errors.New("series have no frequency in common")
+
+// New aligns the series in data. Each Data is usually the result of one
+// GetData call.
+func New(data []model.Data, opts ...Option) (*Frame, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	freq := cfg.frequency
+	if freq == model.FrequencyUnknown {
+		freq = commonFrequency(data)
+		if freq == model.FrequencyUnknown && len(data) > 0 {
+			return nil, ErrNoCommonFrequency
+		}
+	}
+
+	series := make([]model.Series, 0, len(data))
+	for i, d := range data {
+		s, err := d.Series(freq)
+		if err != nil {
+			return nil, fmt.Errorf("series %d: %v", i, err)
+		}
+		series = append(series, s)
+	}
+
+	f := &Frame{Frequency: freq, starts: axis(series, cfg.join)}
+
+	for _, s := range series {
+		f.columns = append(f.columns, align(s, f.starts, cfg))
+	}
+
+	return f, nil
+}
+
+// commonFrequency returns the highest frequency every Data publishes.
+func commonFrequency(data []model.Data) model.Frequency {
+	for _, freq := range []model.Frequency{model.Monthly, model.Quarterly, model.Annual} {
+		common := len(data) > 0
+		for _, d := range data {
+			if len(d.Periods(freq)) == 0 {
+				common = false
+				break
+			}
+		}
+
+		if common {
+			return freq
+		}
+	}
+
+	return model.FrequencyUnknown
+}
+
+func axis(series []model.Series, join Join) []time.Time {
+	counts := make(map[time.Time]int)
+	for _, s := range series {
+		seen := make(map[time.Time]bool)
+		for _, o := range s.Observations {
+			if !seen[o.Start] {
+				seen[o.Start] = true
+				counts[o.Start]++
+			}
+		}
+	}
+
+	var starts []time.Time
+	for start, count := range counts {
+		if join == Outer || count == len(series) {
+			starts = append(starts, start)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	return starts
+}
+
+func align(s model.Series, starts []time.Time, cfg *config) Column {
+	byStart := make(map[time.Time]model.Observation, len(s.Observations))
+	for _, o := range s.Observations {
+		byStart[o.Start] = o
+	}
+
+	cells := make([]Cell, len(starts))
+	last, hasLast := 0.0, false
+
+	for i, start := range starts {
+		if o, ok := byStart[start]; ok {
+			cells[i] = Cell{Value: o.Value, Status: o.Status}
+		} else {
+			cells[i] = Cell{Status: model.Missing, Gap: true}
+
+			switch {
+			case cfg.fill == FillValue:
+				cells[i].Value, cells[i].Status = cfg.fillValue, model.Present
+			case cfg.fill == FillForward && hasLast:
+				cells[i].Value, cells[i].Status = last, model.Present
+			}
+		}
+
+		if cells[i].Status == model.Present && !cells[i].Gap {
+			last, hasLast = cells[i].Value, true
+		}
+	}
+
+	return Column{
+		CDID:      s.CDID,
+		Title:     s.Title,
+		DatasetId: s.DatasetId,
+		DataUnit:  s.DataUnit,
+		PreUnit:   s.PreUnit,
+		Cells:     cells,
+	}
+}
+
+// Len returns the number of periods.
+func (f *Frame) Len() int {
+	return len(f.starts)
+}
+
+// Periods returns the period dates of the rows, e.g. "2016 Q1".
+func (f *Frame) Periods() []string {
+	periods := make([]string, len(f.starts))
+	for i, start := range f.starts {
+		periods[i] = model.FormatPeriod(f.Frequency, start)
+	}
+
+	return periods
+}
+
+// Columns returns the series in the order they were given to New. The
+// columns are shared with f and must not be modified.
+func (f *Frame) Columns() []Column {
+	return f.columns
+}
+
+// Column returns the column for cdid.
+func (f *Frame) Column(cdid string) (Column, bool) {
+	for _, c := range f.columns {
+		if c.CDID == cdid {
+			return c, true
+		}
+	}
+
+	return Column{}, false
+}
+
+// Row returns the i'th period across every series.
+func (f *Frame) Row(i int) Row {
+	row := Row{
+		Period: model.FormatPeriod(f.Frequency, f.starts[i]),
+		Start:  f.starts[i],
+		End:    f.end(f.starts[i]),
+		Cells:  make([]Cell, len(f.columns)),
+	}
+
+	for j, c := range f.columns {
+		row.Cells[j] = c.Cells[i]
+	}
+
+	return row
+}
+
+func (f *Frame) Rows() []Row {
+	rows := make([]Row, f.Len())
+	for i := range rows {
+		rows[i] = f.Row(i)
+	}
+
+	return rows
+}
+
+// Series converts column c back into a Series, leaving out gaps that were
+// not filled.
+func (f *Frame) Series(c Column) model.Series {
+	s := model.Series{
+		CDID:      c.CDID,
+		Title:     c.Title,
+		DatasetId: c.DatasetId,
+		DataUnit:  c.DataUnit,
+		PreUnit:   c.PreUnit,
+		Frequency: f.Frequency,
+	}
+
+	for i, cell := range c.Cells {
+		if cell.Gap && cell.Status != model.Present {
+			continue
+		}
+
+		s.Observations = append(s.Observations, model.Observation{
+			Frequency: f.Frequency,
+			Start:     f.starts[i],
+			End:       f.end(f.starts[i]),
+			Value:     cell.Value,
+			Status:    cell.Status,
+		})
+	}
+
+	return s
+}
+
+func (f *Frame) end(start time.Time) time.Time {
+	switch f.Frequency {
+	case model.Annual:
+		return start.AddDate(1, 0, 0)
+	case model.Quarterly:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
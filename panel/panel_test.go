@@ -0,0 +1,113 @@
+package panel
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func data(cdid string, quarters []string, values []string) model.Data {
+	var periods []model.Period
+	for i, q := range quarters {
+		periods = append(periods, model.Period{PeriodDate: q, Value: values[i]})
+	}
+
+	years := []model.Period{{PeriodDate: "2016", Value: "1"}}
+
+	return model.Data{
+		Years:       &years,
+		Quarters:    &periods,
+		Description: &model.Description{CDID: cdid, Title: cdid + " title"},
+	}
+}
+
+func testData() []model.Data {
+	return []model.Data{
+		data("ABMI", []string{"2016 Q1", "2016 Q2", "2016 Q3"}, []string{"1", "2", "3"}),
+		data("CPCM", []string{"2016 Q3", "2016 Q2", "2016 Q4"}, []string{"30", "x", "40"}),
+	}
+}
+
+func statuses(cells []Cell) []model.ValueStatus {
+	var s []model.ValueStatus
+	for _, c := range cells {
+		s = append(s, c.Status)
+	}
+	return s
+}
+
+func TestOuterJoin(t *testing.T) {
+	f, err := New(testData())
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.Quarterly, f.Frequency)
+	assert.Equal(t, []string{"2016 Q1", "2016 Q2", "2016 Q3", "2016 Q4"}, f.Periods())
+
+	c, ok := f.Column("CPCM")
+	assert.True(t, ok)
+	assert.Equal(t, []model.ValueStatus{model.Missing, model.Suppressed, model.Present, model.Present}, statuses(c.Cells))
+	assert.True(t, c.Cells[0].Gap)
+	assert.False(t, c.Cells[1].Gap)
+
+	row := f.Row(2)
+	assert.Equal(t, "2016 Q3", row.Period)
+	assert.Equal(t, []Cell{{Value: 3}, {Value: 30}}, row.Cells)
+	assert.Equal(t, row.Start.AddDate(0, 3, 0), row.End)
+}
+
+func TestInnerJoin(t *testing.T) {
+	f, err := New(testData(), WithJoin(Inner))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2016 Q2", "2016 Q3"}, f.Periods())
+	assert.Len(t, f.Rows(), 2)
+}
+
+func TestFillForward(t *testing.T) {
+	f, err := New(testData(), WithFillForward())
+
+	assert.Nil(t, err)
+
+	c, _ := f.Column("ABMI")
+	assert.Equal(t, Cell{Value: 3, Status: model.Present, Gap: true}, c.Cells[3])
+
+	c, _ = f.Column("CPCM")
+	assert.Equal(t, Cell{Status: model.Missing, Gap: true}, c.Cells[0])
+}
+
+func TestFillValue(t *testing.T) {
+	f, err := New(testData(), WithFillValue(0))
+
+	assert.Nil(t, err)
+
+	c, _ := f.Column("CPCM")
+	assert.Equal(t, Cell{Value: 0, Status: model.Present, Gap: true}, c.Cells[0])
+}
+
+func TestWithFrequency(t *testing.T) {
+	f, err := New(testData(), WithFrequency(model.Annual))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2016"}, f.Periods())
+}
+
+func TestNoCommonFrequency(t *testing.T) {
+	months := []model.Period{{PeriodDate: "2016 JAN", Value: "1"}}
+	quarters := []model.Period{{PeriodDate: "2016 Q1", Value: "1"}}
+
+	_, err := New([]model.Data{{Months: &months}, {Quarters: &quarters}})
+
+	assert.Equal(t, ErrNoCommonFrequency, err)
+}
+
+func TestSeriesDropsUnfilledGaps(t *testing.T) {
+	f, _ := New(testData())
+
+	c, _ := f.Column("CPCM")
+	s := f.Series(c)
+
+	assert.Equal(t, "CPCM title", s.Title)
+	assert.Equal(t, 3, s.Len())
+	assert.Equal(t, "2016 Q2", s.Observations[0].Period())
+}
@@ -0,0 +1,125 @@
+package transform
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// Aggregation decides how the observations within a lower-frequency period
+// are combined.
+type Aggregation int
+
+const (
+	Sum Aggregation = iota
+	Mean
+	// EndOfPeriod takes the last Present observation, e.g. a December level.
+	EndOfPeriod
+	// StartOfPeriod takes the first Present observation.
+	StartOfPeriod
+)
+
+type resampleConfig struct {
+	requireComplete bool
+}
+
+type ResampleOption func(*resampleConfig)
+
+// RequireComplete makes a period Missing unless every one of its months or
+// quarters is Present, so a year-to-date total is not mistaken for a full
+// year.
+func RequireComplete() ResampleOption {
+	return func(c *resampleConfig) {
+		c.requireComplete = true
+	}
+}
+
+// Resample converts s to a lower frequency: Monthly to Quarterly or Annual,
+// or Quarterly to Annual. Observations that are not Present are left out of
+// the aggregate; a period with none Present is Missing.
+func Resample(s model.Series, to model.Frequency, agg Aggregation, opts ...ResampleOption) (model.Series, error) {
+	cfg := &resampleConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	size := YearLag(s.Frequency) / YearLag(to)
+	if to == model.FrequencyUnknown || to >= s.Frequency || size <= 1 {
+		return model.Series{}, fmt.Errorf("cannot resample %s to %s", s.Frequency, to)
+	}
+
+	groups := make(map[time.Time][]model.Observation)
+	var starts []time.Time
+
+	for _, o := range s.Observations {
+		start := periodStart(o.Start, to)
+		if _, ok := groups[start]; !ok {
+			starts = append(starts, start)
+		}
+		groups[start] = append(groups[start], o)
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	out := s
+	out.Frequency = to
+	out.Observations = make([]model.Observation, 0, len(starts))
+
+	for _, start := range starts {
+		o := model.Observation{
+			Frequency: to,
+			Start:     start,
+			End:       shift(start, to, 1),
+			Status:    model.Missing,
+		}
+		o.Label = o.Period()
+
+		var present []model.Observation
+		for _, child := range groups[start] {
+			if child.Status == model.Present {
+				present = append(present, child)
+			}
+		}
+
+		if len(present) > 0 && (!cfg.requireComplete || len(present) == size) {
+			o.Value, o.Status = aggregate(present, agg), model.Present
+		}
+
+		out.Observations = append(out.Observations, o)
+	}
+
+	return out, nil
+}
+
+// aggregate expects observations in chronological order.
+func aggregate(observations []model.Observation, agg Aggregation) float64 {
+	switch agg {
+	case EndOfPeriod:
+		return observations[len(observations)-1].Value
+	case StartOfPeriod:
+		return observations[0].Value
+	}
+
+	sum := 0.0
+	for _, o := range observations {
+		sum += o.Value
+	}
+
+	if agg == Mean {
+		return sum / float64(len(observations))
+	}
+
+	return sum
+}
+
+func periodStart(t time.Time, freq model.Frequency) time.Time {
+	if freq == model.Quarterly {
+		return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func monthly(t *testing.T) model.Series {
+	return series(t, model.Monthly, map[string]string{
+		"2016 JAN": "1", "2016 FEB": "2", "2016 MAR": "3",
+		"2016 APR": "4", "2016 MAY": "x", "2016 JUN": "6",
+		"2016 JUL": "7",
+	})
+}
+
+func periods(s model.Series) []string {
+	var p []string
+	for _, o := range s.Observations {
+		p = append(p, o.Label)
+	}
+	return p
+}
+
+func TestResampleMonthlyToQuarterly(t *testing.T) {
+	s := monthly(t)
+
+	sum, err := Resample(s, model.Quarterly, Sum)
+	assert.Nil(t, err)
+	assert.Equal(t, model.Quarterly, sum.Frequency)
+	assert.Equal(t, []string{"2016 Q1", "2016 Q2", "2016 Q3"}, periods(sum))
+	assert.Equal(t, []interface{}{6.0, 10.0, 7.0}, values(sum))
+	assert.Equal(t, sum.Observations[0].Start.AddDate(0, 3, 0), sum.Observations[0].End)
+
+	mean, _ := Resample(s, model.Quarterly, Mean)
+	assert.Equal(t, []interface{}{2.0, 5.0, 7.0}, values(mean))
+
+	end, _ := Resample(s, model.Quarterly, EndOfPeriod)
+	assert.Equal(t, []interface{}{3.0, 6.0, 7.0}, values(end))
+
+	start, _ := Resample(s, model.Quarterly, StartOfPeriod)
+	assert.Equal(t, []interface{}{1.0, 4.0, 7.0}, values(start))
+}
+
+func TestResampleRequireComplete(t *testing.T) {
+	s, err := Resample(monthly(t), model.Quarterly, Sum, RequireComplete())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{6.0, nil, nil}, values(s))
+}
+
+func TestResampleToAnnual(t *testing.T) {
+	s, err := Resample(monthly(t), model.Annual, Sum)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2016"}, periods(s))
+	assert.Equal(t, []interface{}{23.0}, values(s))
+
+	q := series(t, model.Quarterly, map[string]string{
+		"2015 Q1": "1", "2015 Q2": "2", "2015 Q3": "3", "2015 Q4": "4", "2016 Q1": "5",
+	})
+
+	s, err = Resample(q, model.Annual, Mean, RequireComplete())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{2.5, nil}, values(s))
+}
+
+func TestResampleRejectsHigherFrequency(t *testing.T) {
+	q := series(t, model.Quarterly, map[string]string{"2016 Q1": "1"})
+
+	_, err := Resample(q, model.Monthly, Sum)
+	assert.NotNil(t, err)
+
+	_, err = Resample(q, model.Quarterly, Sum)
+	assert.NotNil(t, err)
+}
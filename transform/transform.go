@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+)
+
+// The functions in this package return a new Series with one observation per
+// observation of their input, so results stay aligned with it. An output is
+// Missing whenever an input it depends on is not Present, including the
+// first periods that have nothing to compare against. Lags are measured in
+// periods of the series' frequency, not in positions, so a gap in the input
+// never pairs the wrong periods.
+
+// YearLag returns the number of periods in a year for freq: 12 for Monthly,
+// 4 for Quarterly and 1 for Annual.
+func YearLag(freq model.Frequency) int {
+	switch freq {
+	case model.Monthly:
+		return 12
+	case model.Quarterly:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// Change returns the difference between each observation and the one lag
+// periods earlier.
+func Change(s model.Series, lag int) model.Series {
+	return compare(s, lag, func(current float64, previous float64) (float64, bool) {
+		return current - previous, true
+	})
+}
+
+// PercentChange returns the percentage change from the observation lag
+// periods earlier. It is Missing where the earlier value is zero. The result
+// is measured in "%" with no PreUnit.
+func PercentChange(s model.Series, lag int) model.Series {
+	out := compare(s, lag, func(current float64, previous float64) (float64, bool) {
+		if previous == 0 {
+			return 0, false
+		}
+		return (current - previous) / previous * 100, true
+	})
+	out.DataUnit, out.PreUnit = "%", ""
+
+	return out
+}
+
+func PeriodOnPeriod(s model.Series) model.Series {
+	return Change(s, 1)
+}
+
+func PeriodOnPeriodPercent(s model.Series) model.Series {
+	return PercentChange(s, 1)
+}
+
+// YearOnYear compares each observation with the same period a year earlier.
+func YearOnYear(s model.Series) model.Series {
+	return Change(s, YearLag(s.Frequency))
+}
+
+func YearOnYearPercent(s model.Series) model.Series {
+	return PercentChange(s, YearLag(s.Frequency))
+}
+
+// RollingMean returns the mean of each observation and the window-1 periods
+// before it. It is Missing unless all window periods are Present.
+func RollingMean(s model.Series, window int) model.Series {
+	index := byStart(s)
+	out := derive(s)
+
+	for i, o := range s.Observations {
+		if window <= 0 {
+			break
+		}
+
+		sum, complete := 0.0, true
+		for k := 0; k < window; k++ {
+			prev, ok := index[shift(o.Start, s.Frequency, -k)]
+			if !ok || prev.Status != model.Present {
+				complete = false
+				break
+			}
+			sum += prev.Value
+		}
+
+		if complete {
+			present(&out.Observations[i], sum/float64(window))
+		}
+	}
+
+	return out
+}
+
+// CumulativeSum returns the running total of the series. Observations that
+// are not Present are Missing in the output and add nothing to the total.
+func CumulativeSum(s model.Series) model.Series {
+	out := derive(s)
+	total := 0.0
+
+	for i, o := range s.Observations {
+		if o.Status != model.Present {
+			continue
+		}
+
+		total += o.Value
+		present(&out.Observations[i], total)
+	}
+
+	return out
+}
+
+func compare(s model.Series, lag int, fn func(current float64, previous float64) (float64, bool)) model.Series {
+	index := byStart(s)
+	out := derive(s)
+
+	for i, o := range s.Observations {
+		if o.Status != model.Present {
+			continue
+		}
+
+		prev, ok := index[shift(o.Start, s.Frequency, -lag)]
+		if !ok || prev.Status != model.Present {
+			continue
+		}
+
+		if value, ok := fn(o.Value, prev.Value); ok {
+			present(&out.Observations[i], value)
+		}
+	}
+
+	return out
+}
+
+// derive copies s with every observation Missing.
+func derive(s model.Series) model.Series {
+	out := s
+	out.Observations = make([]model.Observation, len(s.Observations))
+
+	for i, o := range s.Observations {
+		o.Value, o.Status, o.Raw = 0, model.Missing, ""
+		out.Observations[i] = o
+	}
+
+	return out
+}
+
+func present(o *model.Observation, value float64) {
+	o.Value, o.Status = value, model.Present
+}
+
+func byStart(s model.Series) map[time.Time]model.Observation {
+	index := make(map[time.Time]model.Observation, len(s.Observations))
+	for _, o := range s.Observations {
+		index[o.Start] = o
+	}
+
+	return index
+}
+
+func shift(start time.Time, freq model.Frequency, periods int) time.Time {
+	switch freq {
+	case model.Monthly:
+		return start.AddDate(0, periods, 0)
+	case model.Quarterly:
+		return start.AddDate(0, 3*periods, 0)
+	default:
+		return start.AddDate(periods, 0, 0)
+	}
+}
@@ -0,0 +1,119 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-apipoc-client/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func series(t *testing.T, freq model.Frequency, periods map[string]string) model.Series {
+	var list []model.Period
+	for date, value := range periods {
+		list = append(list, model.Period{PeriodDate: date, Value: value})
+	}
+
+	var d model.Data
+	switch freq {
+	case model.Monthly:
+		d.Months = &list
+	case model.Quarterly:
+		d.Quarters = &list
+	default:
+		d.Years = &list
+	}
+
+	s, err := d.Series(freq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// values returns the Present values of s, with nil for anything else.
+func values(s model.Series) []interface{} {
+	var v []interface{}
+	for _, o := range s.Observations {
+		if o.Status == model.Present {
+			v = append(v, o.Value)
+		} else {
+			v = append(v, nil)
+		}
+	}
+	return v
+}
+
+func TestYearLag(t *testing.T) {
+	assert.Equal(t, 12, YearLag(model.Monthly))
+	assert.Equal(t, 4, YearLag(model.Quarterly))
+	assert.Equal(t, 1, YearLag(model.Annual))
+}
+
+func TestPeriodOnPeriod(t *testing.T) {
+	s := series(t, model.Quarterly, map[string]string{
+		"2016 Q1": "100", "2016 Q2": "110", "2016 Q3": "x", "2016 Q4": "120",
+	})
+
+	assert.Equal(t, []interface{}{nil, 10.0, nil, nil}, values(PeriodOnPeriod(s)))
+	assert.Equal(t, []interface{}{nil, 10.0, nil, nil}, values(PeriodOnPeriodPercent(s)))
+}
+
+func TestChangeSkipsGapsByPeriod(t *testing.T) {
+	s := series(t, model.Quarterly, map[string]string{
+		"2016 Q1": "100", "2016 Q3": "110", "2016 Q4": "121",
+	})
+
+	assert.Equal(t, []interface{}{nil, nil, 11.0}, values(PeriodOnPeriod(s)))
+	assert.Equal(t, []interface{}{nil, 10.0, nil}, values(Change(s, 2)))
+}
+
+func TestYearOnYear(t *testing.T) {
+	s := series(t, model.Quarterly, map[string]string{
+		"2015 Q1": "200", "2015 Q2": "0", "2016 Q1": "250", "2016 Q2": "10",
+	})
+
+	assert.Equal(t, []interface{}{nil, nil, 50.0, 10.0}, values(YearOnYear(s)))
+	assert.Equal(t, []interface{}{nil, nil, 25.0, nil}, values(YearOnYearPercent(s)))
+}
+
+func TestPercentChangeUnits(t *testing.T) {
+	s := series(t, model.Annual, map[string]string{"2015": "100", "2016": "110"})
+	s.DataUnit, s.PreUnit = "m", "£"
+
+	pct := YearOnYearPercent(s)
+	assert.Equal(t, "%", pct.DataUnit)
+	assert.Equal(t, "", pct.PreUnit)
+
+	change := YearOnYear(s)
+	assert.Equal(t, "m", change.DataUnit)
+	assert.Equal(t, "£", change.PreUnit)
+}
+
+func TestYearOnYearMonthly(t *testing.T) {
+	s := series(t, model.Monthly, map[string]string{
+		"2016 JAN": "10", "2016 FEB": "20", "2017 JAN": "15",
+	})
+
+	assert.Equal(t, []interface{}{nil, nil, 5.0}, values(YearOnYear(s)))
+}
+
+func TestRollingMean(t *testing.T) {
+	s := series(t, model.Annual, map[string]string{
+		"2010": "1", "2011": "2", "2012": "3", "2013": "", "2014": "5", "2015": "6", "2016": "7",
+	})
+
+	assert.Equal(t, []interface{}{nil, nil, 2.0, nil, nil, nil, 6.0}, values(RollingMean(s, 3)))
+}
+
+func TestCumulativeSum(t *testing.T) {
+	s := series(t, model.Annual, map[string]string{
+		"2010": "1", "2011": "2", "2012": "x", "2013": "4",
+	})
+
+	out := CumulativeSum(s)
+
+	assert.Equal(t, []interface{}{1.0, 3.0, nil, 7.0}, values(out))
+	assert.Equal(t, s.Observations[2].Start, out.Observations[2].Start)
+	assert.Equal(t, "x", s.Observations[2].Raw)
+}